@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/schollz/progressbar/v3"
+)
+
+// Extensions des fichiers déjà compressés, stockés dans une archive zip sans recompression
+var compressedExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true, ".avif": true,
+	".mp4": true, ".m4v": true, ".mov": true, ".mkv": true, ".avi": true, ".webm": true,
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".opus": true, ".flac": true,
+	".zip": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".7z": true, ".rar": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".ods": true, ".odp": true,
+	".jar": true, ".apk": true, ".dmg": true,
+}
+
+// Formats d'archive proposés par --archive
+const (
+	formatZip    = "zip"
+	formatTar    = "tar"
+	formatTarGz  = "tar.gz"
+	formatTarZst = "tar.zst"
+)
+
+// Options d'archivage des dossiers et des fichiers multiples
+type archiveOptions struct {
+	Format         string //zip, tar, tar.gz ou tar.zst
+	Compression    string //store, fast, best ou vide pour la compression par défaut
+	FollowSymlinks bool   //Archiver le contenu des liens symboliques au lieu des liens
+}
+
+// Vérifier les valeurs de --archive et --compression
+func parseArchiveOptions(format, compression string) (archiveOptions, error) {
+	opts := archiveOptions{Format: strings.ToLower(format), Compression: strings.ToLower(compression)}
+	switch opts.Format {
+	case "":
+		opts.Format = formatZip
+	case "tgz":
+		opts.Format = formatTarGz
+	case formatZip, formatTar, formatTarGz, formatTarZst:
+	default:
+		return opts, fmt.Errorf("--archive doit valoir zip, tar, tar.gz ou tar.zst")
+	}
+	switch opts.Compression {
+	case "", "store", "fast", "best":
+	default:
+		return opts, fmt.Errorf("--compression doit valoir store, fast ou best")
+	}
+	return opts, nil
+}
+
+// Extension du fichier d'archive
+func (o archiveOptions) Ext() string {
+	return "." + o.Format
+}
+
+// Méthode et niveau de compression des fichiers d'une archive zip
+func (o archiveOptions) zipLevel() (uint16, int) {
+	switch o.Compression {
+	case "store":
+		return zip.Store, 0
+	case "fast":
+		return zip.Deflate, flate.BestSpeed
+	case "best":
+		return zip.Deflate, flate.BestCompression
+	}
+	return zip.Deflate, flate.DefaultCompression
+}
+
+// Écrire dans w une archive des fichiers et dossiers roots au format choisi
+func writeArchive(w io.Writer, roots []archiveRoot, opts archiveOptions, bar *progressbar.ProgressBar) error {
+	if opts.Format == formatZip {
+		return zipSource(w, roots, opts, bar)
+	}
+	return tarSource(w, roots, opts, bar)
+}
+
+// Fichier ou dossier à placer à la racine d'une archive
+type archiveRoot struct {
+	Path string //Chemin sur le disque
+	Name string //Nom dans l'archive
+}
+
+// Nommer les fichiers et dossiers à archiver d'après leur nom,
+// en ajoutant un numéro lorsque deux d'entre eux portent le même nom
+func archiveRoots(paths []string) []archiveRoot {
+	roots := make([]archiveRoot, 0, len(paths))
+	used := map[string]bool{}
+	for _, p := range paths {
+		base := filepath.Base(p)
+		name := base
+		for n := 2; used[name]; n++ {
+			ext := fileExt(base)
+			name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		used[name] = true
+		roots = append(roots, archiveRoot{Path: p, Name: name})
+	}
+	return roots
+}
+
+// Élément rencontré en parcourant un fichier ou dossier à archiver
+type archiveEntry struct {
+	Path string      //Chemin sur le disque
+	Name string      //Nom dans l'archive, séparé par des /
+	Info os.FileInfo //Informations du fichier, ou de la cible si le lien est suivi
+	Link string      //Cible du lien symbolique conservé tel quel
+	Skip string      //Raison pour laquelle l'élément n'est pas archivé
+}
+
+// Parcourir root et appeler fn pour chaque dossier, fichier et lien symbolique.
+// Les liens sont conservés s'ils pointent dans l'archive, ou suivis avec follow.
+func walkArchive(root archiveRoot, follow bool, fn func(archiveEntry) error) error {
+	//Dossiers en cours de parcours, pour ne pas suivre un lien qui boucle
+	parents := map[string]bool{}
+
+	var walk func(diskPath, name string) error
+	walk = func(diskPath, name string) error {
+		info, err := os.Lstat(diskPath)
+		if err != nil {
+			return err
+		}
+		entry := archiveEntry{Path: diskPath, Name: name, Info: info}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(diskPath)
+			if err != nil {
+				return err
+			}
+			//Un lien donné directement en argument est toujours suivi
+			if !follow && name != root.Name {
+				if !insideRoot(root.Name, name, target) {
+					entry.Skip = fmt.Sprintf("le lien symbolique pointe hors du dossier archivé (%s)", target)
+				}
+				entry.Link = target
+				return fn(entry)
+			}
+			entry.Info, err = os.Stat(diskPath)
+			if err != nil {
+				entry.Skip = fmt.Sprintf("la cible du lien symbolique est introuvable (%s)", target)
+				return fn(entry)
+			}
+		}
+
+		if !entry.Info.IsDir() {
+			if !entry.Info.Mode().IsRegular() {
+				entry.Skip = "fichier spécial"
+			}
+			return fn(entry)
+		}
+
+		real, err := filepath.EvalSymlinks(diskPath)
+		if err != nil {
+			return err
+		}
+		if parents[real] {
+			entry.Skip = "le lien symbolique forme une boucle"
+			return fn(entry)
+		}
+		parents[real] = true
+		defer delete(parents, real)
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+		children, err := os.ReadDir(diskPath)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			if err := walk(filepath.Join(diskPath, child.Name()), name+"/"+child.Name()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(root.Path, root.Name)
+}
+
+// Vérifier qu'un lien symbolique placé en name dans l'archive pointe à l'intérieur du dossier rootName
+func insideRoot(rootName, name, target string) bool {
+	if target == "" || filepath.IsAbs(target) || path.IsAbs(filepath.ToSlash(target)) {
+		return false
+	}
+	resolved := path.Join(path.Dir(name), filepath.ToSlash(target))
+	return resolved == rootName || strings.HasPrefix(resolved, rootName+"/")
+}
+
+// Compter la taille totale des fichiers à archiver
+func archiveSize(roots []archiveRoot, follow bool) (int64, error) {
+	var total int64
+	for _, root := range roots {
+		err := walkArchive(root, follow, func(entry archiveEntry) error {
+			if entry.Skip == "" && entry.Link == "" && entry.Info.Mode().IsRegular() {
+				total += entry.Info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return total, nil
+}
+
+// Écrire dans w une archive zip des fichiers et dossiers roots.
+// Les dossiers vides, les liens symboliques et les permissions sont conservés.
+func zipSource(w io.Writer, roots []archiveRoot, opts archiveOptions, bar *progressbar.ProgressBar) error {
+	method, level := opts.zipLevel()
+	// Créer un objet zip.Writer pour écrire
+	zipWriter := zip.NewWriter(w)
+	zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+
+	// Archiver les fichiers
+	for _, root := range roots {
+		err := walkArchive(root, opts.FollowSymlinks, func(entry archiveEntry) error {
+			if entry.Skip != "" {
+				yellow.Printf("\n%s ignoré : %s\n", entry.Path, entry.Skip)
+				return nil
+			}
+			// Créer un header pour l'élément à archiver, avec ses permissions et sa date de modification
+			header, err := zip.FileInfoHeader(entry.Info)
+			if err != nil {
+				return err
+			}
+			header.Name = entry.Name
+			header.Method = method
+
+			switch {
+			case entry.Link != "":
+				//La cible du lien est le contenu de l'entrée
+				header.Method = zip.Store
+				writer, err := zipWriter.CreateHeader(header)
+				if err != nil {
+					return err
+				}
+				_, err = io.WriteString(writer, filepath.ToSlash(entry.Link))
+				return err
+			case entry.Info.IsDir():
+				header.Name += "/"
+				header.Method = zip.Store
+				_, err := zipWriter.CreateHeader(header)
+				return err
+			}
+			if compressedExts[strings.ToLower(filepath.Ext(entry.Name))] {
+				header.Method = zip.Store
+			}
+			return zipFile(zipWriter, header, entry.Path, bar)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return zipWriter.Close()
+}
+
+// Ajouter le fichier filePath à l'archive
+func zipFile(zipWriter *zip.Writer, header *zip.FileHeader, filePath string, bar *progressbar.ProgressBar) error {
+	// Ouvrir le fichier à archiver
+	fileToZip, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer fileToZip.Close()
+
+	writer, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	//Mettre à jour la progressbar au fur et à mesure de la lecture
+	reader := progressbar.NewReader(fileToZip, bar)
+	_, err = io.Copy(writer, &reader)
+	return err
+}
+
+// Compresseur gzip ou zstd d'une archive tar, au niveau demandé par --compression
+func tarCompressor(w io.Writer, opts archiveOptions) (io.WriteCloser, error) {
+	switch opts.Format {
+	case formatTarGz:
+		level := gzip.DefaultCompression
+		switch opts.Compression {
+		case "store":
+			level = gzip.NoCompression
+		case "fast":
+			level = gzip.BestSpeed
+		case "best":
+			level = gzip.BestCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case formatTarZst:
+		//zstd n'a pas de mode sans compression, store utilise le niveau le plus rapide
+		level := zstd.SpeedDefault
+		switch opts.Compression {
+		case "store", "fast":
+			level = zstd.SpeedFastest
+		case "best":
+			level = zstd.SpeedBestCompression
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	}
+	return nopWriteCloser{w}, nil
+}
+
+// Writer dont la fermeture ne fait rien, pour une archive tar non compressée
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Écrire dans w une archive tar, éventuellement compressée, des fichiers et dossiers roots.
+// Les permissions, les liens symboliques et les dossiers vides sont conservés.
+func tarSource(w io.Writer, roots []archiveRoot, opts archiveOptions, bar *progressbar.ProgressBar) error {
+	compressed, err := tarCompressor(w, opts)
+	if err != nil {
+		return err
+	}
+	tarWriter := tar.NewWriter(compressed)
+
+	for _, root := range roots {
+		err := walkArchive(root, opts.FollowSymlinks, func(entry archiveEntry) error {
+			if entry.Skip != "" {
+				yellow.Printf("\n%s ignoré : %s\n", entry.Path, entry.Skip)
+				return nil
+			}
+			header, err := tar.FileInfoHeader(entry.Info, filepath.ToSlash(entry.Link))
+			if err != nil {
+				return err
+			}
+			header.Name = entry.Name
+			if entry.Info.IsDir() {
+				header.Name += "/"
+			}
+			//Le format PAX accepte les noms longs et les dates précises
+			header.Format = tar.FormatPAX
+			if err := tarWriter.WriteHeader(header); err != nil {
+				return err
+			}
+			if entry.Link != "" || !entry.Info.Mode().IsRegular() {
+				return nil
+			}
+			return tarFile(tarWriter, entry.Path, header.Size, bar)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// Ajouter le contenu du fichier filePath à l'archive tar, avec la taille annoncée dans son en-tête
+func tarFile(tarWriter *tar.Writer, filePath string, size int64, bar *progressbar.ProgressBar) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := progressbar.NewReader(file, bar)
+	_, err = io.CopyN(tarWriter, &reader, size)
+	if err == io.EOF {
+		return fmt.Errorf("%s a été modifié pendant l'archivage", filePath)
+	}
+	return err
+}
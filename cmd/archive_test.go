@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// Une archive créée par writeArchive se décompresse à l'identique : contenu, dossiers vides,
+// liens symboliques, permissions et dates de modification
+func TestArchiveRoundTrip(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dossier")
+	modified := time.Date(2023, 5, 17, 10, 30, 0, 0, time.UTC)
+	os.MkdirAll(filepath.Join(src, "sous", "vide"), 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("contenu de a"), 0644)
+	os.WriteFile(filepath.Join(src, "sous", "b.sh"), []byte("#!/bin/sh\necho b\n"), 0755)
+	os.WriteFile(filepath.Join(src, "sous", "secret.txt"), []byte("secret"), 0600)
+	os.Symlink("../a.txt", filepath.Join(src, "sous", "lien"))
+	os.Chtimes(filepath.Join(src, "a.txt"), modified, modified)
+
+	want := map[string]struct {
+		mode    os.FileMode
+		content string
+	}{
+		"a.txt":           {0644, "contenu de a"},
+		"sous/b.sh":       {0755, "#!/bin/sh\necho b\n"},
+		"sous/secret.txt": {0600, "secret"},
+		"sous/vide":       {os.ModeDir | 0755, ""},
+		"sous/lien":       {os.ModeSymlink, "../a.txt"},
+	}
+
+	for _, format := range []string{formatTar, formatTarGz, formatTarZst, formatZip} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "archive")
+			out, err := os.Create(archive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := archiveOptions{Format: format}
+			err = writeArchive(out, archiveRoots([]string{src}), opts, progressbar.DefaultBytesSilent(-1, ""))
+			out.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if detected := detectArchive(archive); detected != format {
+				t.Fatalf("format détecté %q, attendu %q", detected, format)
+			}
+			target := filepath.Join(dir, "extrait")
+			if err := extractArchive(archive, target, format); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(archive); !os.IsNotExist(err) {
+				t.Errorf("l'archive n'a pas été supprimée : %v", err)
+			}
+
+			for name, w := range want {
+				p := filepath.Join(target, "dossier", filepath.FromSlash(name))
+				info, err := os.Lstat(p)
+				if err != nil {
+					t.Errorf("%s : %v", name, err)
+					continue
+				}
+				switch {
+				case w.mode&os.ModeSymlink != 0:
+					if link, err := os.Readlink(p); err != nil || link != w.content {
+						t.Errorf("%s : lien %q, attendu %q (%v)", name, link, w.content, err)
+					}
+					continue
+				case info.Mode() != w.mode:
+					t.Errorf("%s : permissions %v, attendu %v", name, info.Mode(), w.mode)
+				}
+				if info.Mode().IsRegular() {
+					if data, _ := os.ReadFile(p); string(data) != w.content {
+						t.Errorf("%s : contenu %q, attendu %q", name, data, w.content)
+					}
+				}
+			}
+			info, err := os.Stat(filepath.Join(target, "dossier", "a.txt"))
+			if err == nil && !info.ModTime().Equal(modified) {
+				t.Errorf("a.txt : date %v, attendu %v", info.ModTime(), modified)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Résultat du téléchargement d'un transfert
+type transferResult struct {
+	Link    string
+	Files   int
+	Bytes   int64
+	Written []writtenFile //Fichiers écrits, ou dossiers de décompression
+	Err     error
+}
+
+// Fichier écrit par un téléchargement
+type writtenFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"` //Taille téléchargée, celle de l'archive pour un dossier décompressé
+}
+
+// Chemins des fichiers écrits
+func (r transferResult) paths() []string {
+	var paths []string
+	for _, file := range r.Written {
+		paths = append(paths, file.Path)
+	}
+	return paths
+}
+
+// Lire une liste de liens, un par ligne, depuis un fichier ou l'entrée standard si name vaut "-".
+// Les lignes vides et celles commençant par # sont ignorées.
+func readLinks(name string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var links []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		links = append(links, line)
+	}
+	return links, scanner.Err()
+}
+
+// Afficher le tableau récapitulatif des téléchargements
+func printSummary(results []transferResult) {
+	var (
+		succeeded int
+		files     int
+		bytes     int64
+	)
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "LIEN\tSTATUT\tFICHIERS\tTAILLE")
+	for _, result := range results {
+		status := green.Sprint("OK")
+		if result.Err != nil {
+			status = red.Sprint("Échec : " + result.Err.Error())
+		} else {
+			succeeded++
+		}
+		files += result.Files
+		bytes += result.Bytes
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", result.Link, status, result.Files, readableSize(result.Bytes))
+	}
+	w.Flush()
+	fmt.Printf("\n%d réussi(s), %d échec(s), %d fichier(s), %s téléchargés\n", succeeded, len(results)-succeeded, files, readableSize(bytes))
+}
+
+// Afficher le résultat des téléchargements en JSON pour --output json
+func printDownloadJSON(results []transferResult) error {
+	type transferJSON struct {
+		Link  string        `json:"link"`
+		Files []writtenFile `json:"files"`
+		Size  int64         `json:"size"`
+		Error string        `json:"error,omitempty"`
+	}
+	transfers := make([]transferJSON, 0, len(results))
+	for _, result := range results {
+		transfer := transferJSON{Link: result.Link, Files: result.Written, Size: result.Bytes}
+		if transfer.Files == nil {
+			transfer.Files = []writtenFile{}
+		}
+		if result.Err != nil {
+			transfer.Error = result.Err.Error()
+		}
+		transfers = append(transfers, transfer)
+	}
+	return printJSON(map[string]interface{}{"transfers": transfers})
+}
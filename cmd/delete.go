@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"freetranscli/freetransfert"
+
+	"github.com/spf13/cobra"
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:   "delete <url|id>",
+	Short: "Supprimer un transfert que vous avez téléversé",
+	Long: `
+Supprimer un transfert FreeTransfert téléversé depuis cet ordinateur, le lien ne fonctionnera plus.
+Le transfert peut être désigné par son url ou par l'identifiant complet de son entrée dans l'historique.
+La confirmation est acceptée d'office avec --yes.
+Exemple : freetranscli delete https://transfert.free.fr/2kxQZv
+
+Alias : del, rm, revoke`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := deletableEntry(args[0])
+		if err != nil {
+			fail(err)
+		}
+
+		confirm, err := confirmAction(bred.Sprintf("Supprimer le transfert %s ? Attention cette action est irréversible !", entry.URL))
+		if err != nil {
+			fail(err)
+		}
+		if !confirm {
+			yellow.Println("Le transfert n'a pas été supprimé")
+			return
+		}
+
+		if err := deleteTransfer(cmd.Context(), entry); err != nil {
+			fail(err)
+		}
+		green.Println("Le transfert a été supprimé, le lien ne fonctionne plus.")
+	},
+}
+
+// Supprimer le transfert d'une entrée de l'historique auprès de l'API puis marquer l'entrée comme supprimée
+func deleteTransfer(ctx context.Context, entry *historyEntry) error {
+	if err := client.DeleteTransfer(ctx, entry.TransferKey, entry.DeleteKey); err != nil {
+		return fmt.Errorf("suppression impossible : %w", err)
+	}
+
+	//Marquer le transfert comme supprimé dans l'historique
+	now := time.Now()
+	entry.RevokedAt = &now
+	if err := updateHistory(*entry); err != nil {
+		yellow.Println("Le transfert a été supprimé mais l'historique n'a pas pu être mis à jour :", err)
+	}
+	return nil
+}
+
+// Trouver l'entrée de l'historique d'un transfert téléversé à partir de son identifiant complet ou de son url.
+// Un début d'identifiant n'est pas accepté : il pourrait aussi être la clé d'un autre transfert.
+func deletableEntry(ref string) (*historyEntry, error) {
+	entry, err := findHistoryID(ref)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		key, parseErr := freetransfert.ParseKey(ref)
+		if parseErr != nil {
+			return nil, withCode(exitNotFound, fmt.Errorf("%s n'est ni un lien FreeTransfert ni un identifiant de l'historique", ref))
+		}
+		entry, err = findUploadByKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case entry.RevokedAt != nil:
+		return nil, fmt.Errorf("ce transfert a déjà été supprimé le %s", entry.RevokedAt.Local().Format("02/01/2006 à 15:04"))
+	case entry.Direction != directionUpload || entry.DeleteKey == "":
+		return nil, fmt.Errorf("désolé, aucun jeton de suppression n'est connu pour ce transfert : seuls les transferts téléversés depuis cet ordinateur avec l'historique activé peuvent être supprimés")
+	}
+	return entry, nil
+}
+
+// Trouver l'entrée de l'historique dont l'identifiant est exactement id, nil s'il n'y en a pas
+func findHistoryID(id string) (*historyEntry, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	for n := range entries {
+		if entries[n].ID == id {
+			return &entries[n], nil
+		}
+	}
+	return nil, nil
+}
+
+// Trouver le dernier téléversement enregistré pour une clé de transfert
+func findUploadByKey(key string) (*historyEntry, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	var found *historyEntry
+	for n := range entries {
+		if entries[n].TransferKey == key && entries[n].Direction == directionUpload {
+			found = &entries[n]
+		}
+	}
+	if found == nil {
+		return nil, withCode(exitNotFound, fmt.Errorf("désolé, le transfert %s ne figure pas dans l'historique des téléversements, aucun jeton de suppression n'est connu", key))
+	}
+	return found, nil
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+	deleteCmd.Aliases = []string{"del", "rm", "revoke"}
+}
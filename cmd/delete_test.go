@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"freetranscli/freetransfert"
+)
+
+// Enregistrer le téléversement d'un transfert du faux serveur dans l'historique
+func addUpload(t *testing.T, api *fakeAPI, key string) historyEntry {
+	t.Helper()
+	api.add(key, []freetransfert.File{{Path: "a.txt", Size: 1}}, map[string][]byte{"a.txt": []byte("a")})
+	entry := historyEntry{
+		ID:          newHistoryID(),
+		Direction:   directionUpload,
+		TransferKey: key,
+		URL:         freetransfert.ShareURL(key),
+		DeleteKey:   api.transfer(key).DeleteKey,
+	}
+	historic(entry)
+	return entry
+}
+
+func TestDeleteTransfer(t *testing.T) {
+	setupTestDirs(t)
+	api := newFakeAPI(t)
+	added := addUpload(t, api, "key0001")
+
+	for _, ref := range []string{added.ID, added.URL} {
+		entry, err := deletableEntry(ref)
+		if err != nil {
+			t.Fatalf("deletableEntry(%s) : %v", ref, err)
+		}
+		if entry.TransferKey != "key0001" {
+			t.Fatalf("deletableEntry(%s) a trouvé %s", ref, entry.TransferKey)
+		}
+	}
+
+	entry, _ := deletableEntry(added.ID)
+	if err := deleteTransfer(context.Background(), entry); err != nil {
+		t.Fatal(err)
+	}
+	if !api.transfer("key0001").Deleted {
+		t.Fatal("le transfert n'a pas été supprimé sur le serveur")
+	}
+	saved, err := findHistoryID(added.ID)
+	if err != nil || saved == nil || saved.RevokedAt == nil {
+		t.Fatalf("l'entrée n'est pas marquée comme supprimée : %+v, %v", saved, err)
+	}
+	if _, err := deletableEntry(added.ID); err == nil || !strings.Contains(err.Error(), "déjà été supprimé") {
+		t.Fatalf("un transfert supprimé ne devrait plus pouvoir l'être : %v", err)
+	}
+}
+
+func TestDeleteWithoutToken(t *testing.T) {
+	setupTestDirs(t)
+	api := newFakeAPI(t)
+	api.add("key0001", []freetransfert.File{{Path: "a.txt"}}, nil)
+	historic(historyEntry{Direction: directionDownload, TransferKey: "key0001", URL: freetransfert.ShareURL("key0001")})
+
+	_, err := deletableEntry("https://transfert.free.fr/key0001")
+	if err == nil {
+		t.Fatal("un transfert téléchargé ne peut pas être supprimé")
+	}
+	if code := exitCode(err); code != exitNotFound {
+		t.Fatalf("code de sortie %d, attendu %d", code, exitNotFound)
+	}
+	if api.transfer("key0001").Deleted {
+		t.Fatal("le transfert ne devrait pas avoir été supprimé")
+	}
+}
+
+func TestDeleteAPIError(t *testing.T) {
+	setupTestDirs(t)
+	api := newFakeAPI(t)
+	added := addUpload(t, api, "key0001")
+	api.deleteStatus = http.StatusInternalServerError
+	api.deleteBody = `{"error":"internal","message":"erreur du serveur"}`
+
+	entry, err := deletableEntry(added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = deleteTransfer(context.Background(), entry)
+	if err == nil || !strings.Contains(err.Error(), "erreur du serveur") {
+		t.Fatalf("erreur de l'API attendue, obtenu %v", err)
+	}
+	if code := exitCode(err); code != exitAPI {
+		t.Fatalf("code de sortie %d, attendu %d", code, exitAPI)
+	}
+	saved, _ := findHistoryID(added.ID)
+	if saved.RevokedAt != nil {
+		t.Fatal("l'entrée ne doit pas être marquée comme supprimée si l'API a échoué")
+	}
+}
+
+// Une clé qui commence comme l'identifiant d'une autre entrée désigne le transfert de cette clé
+func TestDeleteKeyNotIDPrefix(t *testing.T) {
+	setupTestDirs(t)
+	api := newFakeAPI(t)
+	other := addUpload(t, api, "key0001")
+	key := other.ID[:4]
+	addUpload(t, api, key)
+
+	entry, err := deletableEntry(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.TransferKey != key {
+		t.Fatalf("%s désigne le transfert %s au lieu de %s", key, entry.TransferKey, key)
+	}
+	if _, err := deletableEntry(other.ID[:6]); err == nil {
+		t.Fatal("un début d'identifiant ne doit pas être accepté pour une suppression")
+	}
+}
+
+// Le jeton de suppression n'apparaît jamais dans history --json
+func TestHistoryJSONHidesDeleteKey(t *testing.T) {
+	var out bytes.Buffer
+	dataOut = &out
+	t.Cleanup(func() { dataOut = os.Stdout })
+
+	writeHistoryJSON([]historyEntry{{ID: "abcd1234", Direction: directionUpload, TransferKey: "key0001", DeleteKey: "jeton-secret"}})
+	if strings.Contains(out.String(), "jeton-secret") || strings.Contains(out.String(), "deleteKey") {
+		t.Fatalf("le jeton de suppression est affiché : %s", out.String())
+	}
+	if !strings.Contains(out.String(), "abcd1234") {
+		t.Fatalf("l'entrée n'est pas affichée : %s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// Définir les dossiers de configuration, de données et de cache selon l'OS.
+// Sous Linux et macOS les variables XDG_CONFIG_HOME, XDG_DATA_HOME et XDG_CACHE_HOME sont respectées.
+func setupDirs() {
+	if runtime.GOOS == "windows" {
+		home = os.Getenv("USERPROFILE")                                    //C:\Users\%USERNAME%
+		configDir = filepath.Join(os.Getenv("APPDATA"), "freetranscli")    //C:\Users\%USERNAME%\AppData\Roaming\freetranscli
+		dataDir = filepath.Join(os.Getenv("LOCALAPPDATA"), "freetranscli") //C:\Users\%USERNAME%\AppData\Local\freetranscli
+		cacheDir = filepath.Join(os.Getenv("LOCALAPPDATA"), "freetranscli", "cache")
+	} else {
+		home = os.Getenv("HOME")                                                         //~
+		configDir = filepath.Join(xdgDir("XDG_CONFIG_HOME", ".config"), "freetranscli")  //~/.config/freetranscli
+		dataDir = filepath.Join(xdgDir("XDG_DATA_HOME", ".local/share"), "freetranscli") //~/.local/share/freetranscli
+		cacheDir = filepath.Join(xdgDir("XDG_CACHE_HOME", ".cache"), "freetranscli")     //~/.cache/freetranscli
+	}
+	historicfile = filepath.Join(dataDir, "historic.jsonl")
+
+	// créer les répertoires s'ils n'existent pas
+	for _, dir := range []string{configDir, dataDir, cacheDir} {
+		os.MkdirAll(dir, 0755)
+	}
+}
+
+// Valeur d'une variable XDG, ou le dossier par défaut dans le répertoire de l'utilisateur
+func xdgDir(env, fallback string) string {
+	// La spécification demande d'ignorer les chemins relatifs
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(home, fallback)
+}
+
+// Anciens emplacements des fichiers de FreeTransCLI, utilisés avant le passage aux dossiers XDG
+func legacyDirs() (string, []string) {
+	var oldConfig string
+	if runtime.GOOS == "windows" {
+		oldConfig = os.Getenv("APPDATA") + "/freetranscli"
+	} else {
+		oldConfig = os.Getenv("HOME") + "/.config/freetranscli"
+	}
+	// L'ancien dossier temporaire était construit sans séparateur (/tmpFreeTransCLI_temp sous Linux)
+	oldTemp := []string{
+		os.TempDir() + "FreeTransCLI_temp",
+		filepath.Join(os.TempDir(), "FreeTransCLI_temp"),
+	}
+	return oldConfig, oldTemp
+}
+
+// Déplacer une seule fois les fichiers des anciens emplacements vers les dossiers actuels
+func migrateLegacyFiles() error {
+	oldConfig, oldTemp := legacyDirs()
+
+	//La configuration ne change de place que si XDG_CONFIG_HOME pointe ailleurs
+	if filepath.Clean(oldConfig) != filepath.Clean(configDir) {
+		for _, name := range []string{"config.yaml", "icon.png"} {
+			if err := moveFile(filepath.Join(oldConfig, name), filepath.Join(configDir, name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, dir := range oldTemp {
+		if filepath.Clean(dir) == filepath.Clean(cacheDir) {
+			continue
+		}
+		//L'historique au nouveau format est ajouté à la suite de l'historique actuel
+		if err := appendFile(filepath.Join(dir, "historic.jsonl"), historicfile); err != nil {
+			return err
+		}
+		//L'ancien historique au format yaml sera importé par migrateHistory
+		if err := moveFile(filepath.Join(dir, "historic.yaml"), filepath.Join(dataDir, "historic.yaml")); err != nil {
+			return err
+		}
+		os.RemoveAll(dir)
+	}
+	return nil
+}
+
+// Déplacer src vers dst si src existe et que dst n'existe pas encore
+func moveFile(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return nil
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	//Le renommage échoue entre deux systèmes de fichiers, on copie puis on supprime
+	if err := appendFile(src, dst); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Ajouter le contenu de src à la fin de dst puis supprimer src
+func appendFile(src, dst string) error {
+	in, err := os.Open(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -1,15 +1,17 @@
 package cmd
 
 import (
-	"archive/zip"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
-	"time"
+	"sync"
+
+	"freetranscli/freetransfert"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/gen2brain/beeep"
@@ -18,72 +20,597 @@ import (
 	"github.com/spf13/viper"
 )
 
-func Unzip(source, target string) error {
-	var size int64
-	err := filepath.Walk(source, func(_ string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			size += info.Size()
+var (
+	dldZip          bool
+	dldOnly         string
+	dldConnections  int
+	dldFromFile     string
+	dldJobs         int
+	dldPassword     bool
+	dldPasswordFile string
+	dldOnConflict   string
+	// Destination donnée par -o/--output, - pour la sortie standard
+	dldOutput string
+	// Vrai lorsque plusieurs transferts sont téléchargés en même temps
+	dldQuiet bool
+	// Empêche plusieurs téléchargements de poser une question en même temps
+	promptMu sync.Mutex
+	// Chemins où un téléchargement en cours va écrire, considérés comme pris par les autres
+	reservedMu    sync.Mutex
+	reservedPaths = map[string]bool{}
+)
+
+// Chemin local d'un fichier du transfert, en refusant les chemins qui sortiraient du dossier de téléchargement
+func localPath(dir, remote string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(remote))
+	if clean == "." || filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("chemin invalide dans le transfert : %s", remote)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// Extensions doubles traitées comme une seule extension
+var doubleExts = []string{".tar.gz", ".tar.zst"}
+
+// Extension d'un nom de fichier, en gardant entières les extensions doubles comme .tar.gz
+func fileExt(name string) string {
+	for _, ext := range doubleExts {
+		if len(name) > len(ext) && strings.EqualFold(name[len(name)-len(ext):], ext) {
+			return name[len(name)-len(ext):]
+		}
+	}
+	return filepath.Ext(name)
+}
+
+// Premier chemin libre de la forme "nom (1).ext", "nom (2).ext"… si p existe déjà
+// ou est réservé par un téléchargement en cours
+func numberedPath(p string) string {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+	return freePath(p)
+}
+
+// Indiquer si p existe ou est réservé, reservedMu doit être verrouillé
+func pathTaken(p string) bool {
+	if reservedPaths[p] {
+		return true
+	}
+	_, err := os.Lstat(p)
+	return !os.IsNotExist(err)
+}
+
+// numberedPath sans verrouiller reservedMu
+func freePath(p string) string {
+	if !pathTaken(p) {
+		return p
+	}
+	ext := fileExt(p)
+	if info, err := os.Stat(p); err == nil && info.IsDir() {
+		ext = ""
+	}
+	base := strings.TrimSuffix(p, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, n, ext)
+		if !pathTaken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// Créer la progressbar d'un téléchargement, silencieuse lorsque plusieurs transferts sont téléchargés en même temps
+func downloadBar(size int64, label string) *progressbar.ProgressBar {
+	if dldQuiet {
+		return progressbar.DefaultBytesSilent(size, label)
+	}
+	return bytesBar(size, label)
+}
+
+// Valeur de l'option -o/--output de download. text et json choisissent le format de sortie
+// comme l'option globale --output, les autres valeurs indiquent où écrire les fichiers
+// (./json pour un fichier nommé json).
+type downloadOutputValue struct{}
+
+func (downloadOutputValue) String() string { return dldOutput }
+func (downloadOutputValue) Type() string   { return "string" }
+
+func (downloadOutputValue) Set(value string) error {
+	switch value {
+	case "text", "json":
+		outputFormat = value
+	case "":
+		return errors.New("chemin vide")
+	default:
+		dldOutput = value
+	}
+	return nil
+}
+
+// Destination donnée par -o pour count fichiers : un dossier existant ou terminé par /
+// reçoit les fichiers du transfert, sinon output est le chemin de l'unique fichier téléchargé.
+// Renvoie le dossier de téléchargement et le chemin du fichier, vide pour un dossier.
+func outputTarget(output string, count int) (string, string, error) {
+	info, err := os.Stat(output)
+	isDir := err == nil && info.IsDir()
+	if isDir || strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator)) {
+		return output, "", nil
+	}
+	if count == 1 {
+		return filepath.Dir(output), output, nil
+	}
+	if err == nil {
+		return "", "", fmt.Errorf("%s n'est pas un dossier, impossible d'y écrire %d fichiers", output, count)
+	}
+	return output, "", nil
+}
+
+// Sélectionner les fichiers du transfert à télécharger selon les options --zip et --only
+func selectFiles(info *freetransfert.Transfer) ([]freetransfert.File, error) {
+	if dldZip {
+		if info.Zip == nil || info.Zip.Path == "" {
+			return nil, fmt.Errorf("ce transfert ne propose pas d'archive")
+		}
+		return []freetransfert.File{*info.Zip}, nil
+	}
+
+	var files []freetransfert.File
+	for _, file := range info.Files {
+		if dldOnly != "" {
+			//Le motif peut viser le chemin complet ou seulement le nom du fichier
+			full, _ := path.Match(dldOnly, file.Path)
+			base, _ := path.Match(dldOnly, path.Base(file.Path))
+			if !full && !base {
+				continue
+			}
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		if dldOnly != "" {
+			return nil, fmt.Errorf("aucun fichier ne correspond à %s", dldOnly)
+		}
+		return nil, fmt.Errorf("le transfert ne contient aucun fichier")
+	}
+	return files, nil
+}
+
+// Politique appliquée lorsqu'un fichier téléchargé existe déjà : celle de --on-conflict,
+// sinon poser la question si c'est possible, ou ignorer le fichier
+func conflictPolicy() string {
+	if dldOnConflict != "" {
+		return dldOnConflict
+	}
+	if interactive() {
+		return "ask"
+	}
+	return "skip"
+}
+
+// Réserver p jusqu'à releasePath. Si un autre téléchargement en cours l'a déjà réservé,
+// le premier chemin libre "nom (1).ext"… est réservé à la place.
+func reservePath(p string) string {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+	if reservedPaths[p] {
+		p = freePath(p)
+	}
+	reservedPaths[p] = true
+	return p
+}
+
+// Libérer un chemin réservé par reservePath ou resolveConflict
+func releasePath(p string) {
+	reservedMu.Lock()
+	defer reservedMu.Unlock()
+	delete(reservedPaths, p)
+}
+
+// Demander quoi faire si filePath existe déjà ou est pris par un autre téléchargement en cours,
+// une seule question est posée à la fois. Renvoie le chemin où écrire le fichier téléchargé,
+// réservé jusqu'à releasePath, ou "" si l'utilisateur annule.
+func resolveConflict(filePath, remote string) string {
+	reservedMu.Lock()
+	if !pathTaken(filePath) {
+		reservedPaths[filePath] = true
+		reservedMu.Unlock()
+		return filePath
+	}
+	reservedMu.Unlock()
+
+	switch conflictPolicy() {
+	case "skip":
+		yellow.Printf("%s existe déjà, fichier ignoré\n", filePath)
+		return ""
+	case "overwrite":
+		return reservePath(filePath)
+	case "rename":
+		reservedMu.Lock()
+		defer reservedMu.Unlock()
+		filePath = freePath(filePath)
+		reservedPaths[filePath] = true
+		return filePath
+	}
+
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	//Un autre téléchargement a pu libérer ou prendre le nom pendant l'attente
+	renamed := numberedPath(filePath)
+	var choice string
+	inquirer = &survey.Select{
+		Message: fmt.Sprintf("Le fichier %v existe déjà, que voulez-vous faire ?", remote),
+		Options: []string{"Renommer le fichier téléchargé", "Renommer l'ancien fichier", "Remplacer", "Annuler"},
+	}
+	if err := ask(inquirer, &choice); err != nil {
+		return ""
+	}
+
+	switch choice {
+	case "Renommer le fichier téléchargé":
+		var input string
+		prompt := &survey.Input{
+			Message: "Nom du fichier :",
+			Default: filepath.Base(renamed),
+		}
+		if err := ask(prompt, &input); err != nil || input == "" {
+			return ""
+		}
+		return reservePath(filepath.Join(filepath.Dir(filePath), input))
+	case "Renommer l'ancien fichier":
+		var input string
+		prompt := &survey.Input{
+			Message: "Nom du fichier :",
+			Default: filepath.Base(renamed),
+		}
+		if err := ask(prompt, &input); err != nil || input == "" {
+			return ""
 		}
-		return nil
-	})
+		if err := os.Rename(filePath, filepath.Join(filepath.Dir(filePath), input)); err != nil {
+			red.Printf("Erreur : impossible de renommer %s : %s\n", filePath, err)
+			return ""
+		}
+		return reservePath(filePath)
+	case "Remplacer":
+		//Yes or no
+		var danger bool
+		inquirer := &survey.Confirm{
+			Message: bred.Sprint("Êtes-vous sûr de vouloir remplacer le fichier ?\nAttention cet action est irréversible !"),
+		}
+		if err := ask(inquirer, &danger); err != nil || !danger {
+			return ""
+		}
+		//L'ancien fichier est remplacé une fois le téléchargement terminé
+		return reservePath(filePath)
+	}
+	return ""
+}
+
+// Écrire un fichier du transfert sur la sortie standard, déchiffré si le transfert est chiffré.
+// Renvoie le nombre d'octets écrits.
+func streamFile(ctx context.Context, access transferAccess, file freetransfert.File, label string) (int64, error) {
+	url, err := client.FileURL(ctx, access.Key, file.Path, access.Password)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	zipReader, err := zip.OpenReader(source)
+	content, err := client.Download(ctx, url, 0, "")
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer zipReader.Close()
+	defer content.Close()
 
-	bar := progressbar.DefaultBytes(
-		size,
-		cyan.Sprint("Décompression"),
-	)
-	for _, file := range zipReader.File {
-		zippedFile, err := file.Open()
+	bar := downloadBar(content.Length, label)
+	defer bar.Clear()
+	var r io.Reader = io.TeeReader(content, bar)
+	if access.Secret != nil {
+		//Seuls les morceaux authentifiés sont écrits, un contenu modifié interrompt l'écriture
+		r, err = freetransfert.NewDecryptReader(r, access.Secret)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		defer zippedFile.Close()
+	}
+	return io.Copy(dataOut, r)
+}
+
+// Informations nécessaires pour télécharger les fichiers d'un transfert
+type transferAccess struct {
+	Key      string
+	Password string
+	// Clé de déchiffrement lue dans le fragment du lien, nil si le transfert n'est pas chiffré
+	Secret []byte
+}
+
+// Télécharger un fichier du transfert vers filePath.
+// Renvoie le chemin du fichier écrit, ou "" si l'utilisateur a annulé.
+func downloadFile(ctx context.Context, access transferAccess, file freetransfert.File, filePath, label string) (string, error) {
+	key := access.Key
+	url, err := client.FileURL(ctx, key, file.Path, access.Password)
+	if err != nil {
+		return "", err
+	}
+
+	filePath = resolveConflict(filePath, file.Path)
+	if filePath == "" {
+		return "", nil
+	}
+	defer releasePath(filePath)
+
+	// Créer les dossiers parents si le fichier est dans un sous-dossier du transfert
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return "", err
+	}
+
+	// Reprendre un téléchargement interrompu si un fichier .part correspond au fichier demandé
+	part, _ := partPaths(filePath)
+	offset, etag := resumeOffset(filePath, key, file.Path, file.Size)
+	//Renommer le fichier terminé, ou le déchiffrer si le transfert est chiffré
+	finish := func() error {
+		if access.Secret != nil {
+			return finishEncryptedPart(filePath, access.Secret)
+		}
+		return finishPart(filePath)
+	}
+	// Télécharger en plusieurs parties si demandé, sinon ou si le serveur ne le permet pas en un seul flux.
+	// La taille déclarée n'est qu'une indication : seule la réponse du serveur compte.
+	if dldConnections > 1 && offset == 0 {
+		err := parallelDownload(ctx, url, part, dldConnections, label)
+		if err == nil {
+			return filePath, finish()
+		}
+		if !errors.Is(err, freetransfert.ErrRangeUnsupported) {
+			os.Remove(part)
+			return "", err
+		}
+		yellow.Println("Le serveur ne permet pas le téléchargement en plusieurs parties, téléchargement en un seul flux")
+	}
+
+	// Télécharger le fichier
+	content, err := client.Download(ctx, url, offset, etag)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	if content.Offset > 0 {
+		yellow.Printf("Reprise du téléchargement de %s à partir de %s\n", file.Path, readableSize(content.Offset))
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if content.Offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	out, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	err = writePartInfo(filePath, partInfo{TransferKey: key, Path: file.Path, Size: file.Size, ETag: content.ETag})
+	if err != nil {
+		return "", err
+	}
+
+	total := int64(-1)
+	if content.Length >= 0 {
+		total = content.Offset + content.Length
+	}
+	bar := downloadBar(total, label)
+	bar.Set64(content.Offset)
+
+	_, err = io.Copy(io.MultiWriter(out, bar), content)
+	if err != nil {
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+
+	//Vérifier que le fichier a été entièrement téléchargé avant de le renommer,
+	//d'après la taille annoncée par le serveur et non celle déclarée à la création du transfert
+	if stat, err := os.Stat(part); err != nil {
+		return "", err
+	} else if total >= 0 && stat.Size() != total {
+		return "", fmt.Errorf("fichier incomplet (%s sur %s), relancez la commande pour reprendre", readableSize(stat.Size()), readableSize(total))
+	}
+	if err := finish(); err != nil {
+		return "", err
+	}
+	bar.Clear()
+	return filePath, nil
+}
+
+// Télécharger les fichiers sélectionnés d'un transfert dans le dossier de téléchargement
+func downloadTransfer(ctx context.Context, link string, vp *viper.Viper) transferResult {
+	result := transferResult{Link: link}
+	key, err := freetransfert.ParseKey(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	access := transferAccess{Key: key}
+	access.Secret, err = freetransfert.ParseEncryptionKey(link)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if access.Secret != nil && dldZip {
+		result.Err = withCode(exitUsage, errors.New("l'archive d'un transfert chiffré ne peut pas être déchiffrée, téléchargez les fichiers sans --zip"))
+		return result
+	}
+	// Obtenir des informations sur le transfert
+	info, err := client.GetTransfer(ctx, key)
+	if err != nil {
+		result.Err = err
+		return result
+	}
 
-		now := time.Now()
-		dateTimeString := now.Format("02_01_2006 15:04:05")
-		err = os.MkdirAll(target+"/freetransfert "+dateTimeString+"/", 0777)
+	files, err := selectFiles(info)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if dldOutput == "-" && len(files) > 1 {
+		result.Err = withCode(exitUsage, fmt.Errorf("le transfert contient %d fichiers, choisissez-en un avec --only pour l'écrire sur la sortie standard", len(files)))
+		return result
+	}
+	//Dossier de téléchargement, ou destination donnée par -o
+	dir, target := vp.GetString("cli.dld"), ""
+	if dldOutput != "" && dldOutput != "-" {
+		dir, target, err = outputTarget(dldOutput, len(files))
 		if err != nil {
-			return err
+			result.Err = withCode(exitUsage, err)
+			return result
 		}
-		extractedFile, err := os.Create(target + "/freetransfert " + dateTimeString + "/" + file.Name)
+	}
+
+	//Demander le mot de passe si le transfert est protégé
+	if info.PasswordProtected || dldPassword || dldPasswordFile != "" {
+		access.Password, err = readPassword(dldPasswordFile, false)
 		if err != nil {
-			return err
+			result.Err = err
+			return result
 		}
-		defer extractedFile.Close()
+	}
 
-		_, err = io.Copy(extractedFile, zippedFile)
+	failed := 0
+	var firstErr error
+	for n, file := range files {
+		label := "Téléchargement"
+		if len(files) > 1 {
+			label = fmt.Sprintf("Téléchargement (%d/%d)", n+1, len(files))
+		}
+		var (
+			filePath string
+			size     int64
+			err      error
+		)
+		if dldOutput == "-" {
+			filePath = "-"
+			size, err = streamFile(ctx, access, file, label)
+		} else {
+			//Le chemin du fichier dans le transfert est conservé sous le dossier de téléchargement
+			filePath = target
+			if filePath == "" {
+				filePath, err = localPath(dir, file.Path)
+			}
+			if err == nil {
+				filePath, err = downloadFile(ctx, access, file, filePath, label)
+			}
+		}
+		if freetransfert.IsUnauthorized(err) {
+			result.Err = withCode(exitAPI, errors.New("mot de passe manquant ou incorrect"))
+			return result
+		}
+		if errors.Is(err, freetransfert.ErrCorrupted) && filePath == "-" {
+			red.Printf("Erreur : %s a été modifié ou endommagé, l'écriture a été interrompue\n", file.Path)
+			if failed++; firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if errors.Is(err, freetransfert.ErrCorrupted) {
+			red.Printf("Erreur : %s a été modifié ou endommagé, le fichier déchiffré a été supprimé\n", file.Path)
+			if failed++; firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
 		if err != nil {
-			return err
+			red.Printf("Erreur lors du téléchargement de %s : %s\n", file.Path, err.Error())
+			if failed++; firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if filePath == "" {
+			continue
+		}
+		result.Files++
+		if filePath == "-" {
+			//Le contenu écrit sur la sortie standard n'est pas décompressé
+			result.Bytes += size
+			result.Written = append(result.Written, writtenFile{Path: filePath, Size: size})
+			continue
+		}
+		if stat, err := os.Stat(filePath); err == nil {
+			size = stat.Size()
+			result.Bytes += size
+		}
+		if dldQuiet {
+			fmt.Println(green.Sprint("Téléchargé :"), filePath)
+		}
+
+		//Décompresser l'archive zip du transfert et les archives tar, tar.gz ou tar.zst,
+		//sauf si -o désigne le fichier à écrire : l'archive est alors conservée telle quelle
+		format := detectArchive(filePath)
+		if target == "" && vp.GetBool("cli.unzip") && ((dldZip && format == formatZip) || strings.HasPrefix(format, formatTar)) {
+			folder, err := unzipFolder(vp, dir, filePath, key)
+			if err == nil {
+				err = extractArchive(filePath, folder, format)
+			}
+			if err != nil {
+				red.Printf("Erreur lors de la décompression : %s\n", err.Error())
+			} else {
+				filePath = folder
+			}
 		}
-		bar.Add(int(file.UncompressedSize64))
+		result.Written = append(result.Written, writtenFile{Path: filePath, Size: size})
 	}
-	bar.Finish()
-	err = os.Remove(source)
-	if err != nil {
-		return err
+	if failed > 0 {
+		//Le code de sortie est celui de la première erreur
+		result.Err = withCode(exitCode(firstErr), fmt.Errorf("%d fichier(s) n'ont pas pu être téléchargés", failed))
 	}
-	return nil
+
+	//Enregistre les fichiers téléchargés dans l'historique si l'historique est activé
+	if vp.GetBool("cli.history") && result.Files > 0 {
+		filetype := "file"
+		if dldZip {
+			filetype = "archive"
+		} else if result.Files > 1 {
+			filetype = "files"
+		}
+		historic(historyEntry{
+			Direction:   directionDownload,
+			TransferKey: key,
+			URL:         shareURLFor(key, access.Secret),
+			Paths:       result.paths(),
+			Type:        filetype,
+			Size:        result.Bytes,
+			ExpiresAt:   info.ExpiresAt,
+		})
+	}
+	return result
 }
 
 // downloadCmd represents the download command
 var downloadCmd = &cobra.Command{
 	Use:   "download",
-	Short: "Télécharger un fichier depuis FreeTransfert grâce à l'url du fichier",
+	Short: "Télécharger les fichiers d'un transfert FreeTransfert grâce à son url",
 	Long: `
-Télécharger un fichier depuis FreeTransfert grâce à l'url du fichier
-Exemple : freetranscli download https://freetranscli.fr/2kxQZv
-Le fichier sera téléchargé dans le dossier qui est enregistré dans la configuration
+Télécharger les fichiers d'un ou plusieurs transferts FreeTransfert grâce à leur url
+Exemple : freetranscli download https://transfert.free.fr/2kxQZv https://transfert.free.fr/9aBcDe
+Les fichiers seront téléchargés dans le dossier qui est enregistré dans la configuration, en conservant leurs sous-dossiers
+Un téléchargement interrompu reprend là où il s'était arrêté lorsque vous relancez la commande
+
+Options :
+  --zip           Télécharger l'archive contenant tous les fichiers du transfert
+  --only <motif>  Ne télécharger que les fichiers correspondant au motif (ex : "*.pdf")
+  --connections N Télécharger chaque fichier avec N connexions en parallèle
+  --from-file <f> Lire les liens à télécharger depuis un fichier, un par ligne (- pour l'entrée standard)
+  --jobs N        Nombre de transferts téléchargés en même temps (3 par défaut)
+  --password      Demander le mot de passe même si le transfert ne semble pas protégé
+  --password-file <f> Lire le mot de passe d'un transfert protégé depuis un fichier
+  -o, --output <f|d>  Écrire le fichier téléchargé dans f, ou les fichiers dans le dossier d
+                      (dossier existant ou terminé par /) au lieu du dossier de téléchargement
+  -o -                Écrire le fichier sur la sortie standard, les messages passent sur la sortie d'erreur
+                      (le transfert ne doit contenir qu'un fichier, ou un seul choisi avec --only)
+  -o json, -o text    Choisir le format de sortie comme --output (./json pour un fichier nommé json)
+  --on-conflict <p>   Si un fichier existe déjà : ask (demander), skip (ignorer), overwrite (remplacer)
+                      ou rename (écrire le nouveau fichier sous le nom "nom (1).ext").
+                      Sans question possible (--yes, --non-interactive, script), skip par défaut
+Le mot de passe d'un transfert protégé est demandé, ou lu dans la variable FREETRANSCLI_PASSWORD
+Avec la décompression automatique, l'archive zip (--zip) et les archives tar, tar.gz et tar.zst
+sont décompressées dans un nouveau dossier du dossier de téléchargement (ou du dossier donné à -o).
+Un fichier donné à -o n'est jamais décompressé.
+Les fichiers d'un transfert chiffré sont déchiffrés et vérifiés si le lien contient la clé (après le #)
 
 Alias : d, dld, dl, down`,
 	Run: func(cmd *cobra.Command, args []string) {
-		isZip := false
 		//Obtenir le fichier de configuration
 		vp := viper.New()
 		vp.SetConfigName("config")
@@ -91,176 +618,121 @@ Alias : d, dld, dl, down`,
 		vp.AddConfigPath(configDir)
 		err := vp.ReadInConfig()
 		if err != nil {
-			red.Println(err)
-			os.Exit(0)
+			fail(withCode(exitIO, err))
 		}
-		if len(args) == 0 {
+		links := args
+		if dldFromFile != "" {
+			list, err := readLinks(dldFromFile)
+			if err != nil {
+				fail(fmt.Errorf("impossible de lire la liste de liens : %w", err))
+			}
+			links = append(links, list...)
+		}
+		if len(links) == 0 {
 			var input string
 			prompt := &survey.Input{
 				Message: "Lien FreeTransCLI :",
 			}
-			survey.AskOne(prompt, &input)
-			args = append(args, input)
-			println(args[0])
+			if err := ask(prompt, &input); err != nil {
+				fail(withCode(exitUsage, errors.New("aucun lien FreeTransfert indiqué")))
+			}
 			//Retirer les guillemets si il y en a
-			args[0] = strings.ReplaceAll(args[0], "'", "")
-		}
-
-		//Séparer les / pour ne garder que le code du transfert
-		transfertKey := strings.Split(args[0], "/")
-		// Obtenir des informations sur le transfert
-		resp, err := http.Get("https://api.scw.iliad.fr/freetransfert/v2/transfers/" + transfertKey[3])
-		if err != nil {
-			red.Printf("Erreur (1er fetch) : %s\n", err.Error())
-			return
+			links = append(links, strings.ReplaceAll(input, "'", ""))
 		}
-		defer resp.Body.Close()
-
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			red.Printf("Erreur (1er fetch) : %s\n", err.Error())
-			return
-		}
-
-		var info map[string]interface{}
-		if err := json.Unmarshal(body, &info); err != nil {
-			fmt.Printf("Erreur (1er fetch) : %s\n", err.Error())
-			return
-		}
-
-		if info["error"] != nil || info["message"] != nil {
-			errMsg, ok := info["message"].(string)
-			if !ok {
-				errMsg = fmt.Sprintf("%v", info["message"])
+		if dldOnly != "" {
+			if _, err := path.Match(dldOnly, ""); err != nil {
+				fail(withCode(exitUsage, fmt.Errorf("motif --only invalide : %s", dldOnly)))
 			}
-			red.Printf("Erreur (1er fetch) : %s\n", errMsg)
-			return
 		}
 
-		var path string
-		if zip, ok := info["zip"].(map[string]interface{}); ok {
-			if zipPath, ok := zip["path"].(string); ok {
-				path = zipPath
-				isZip = true
-			}
+		if dldConnections < 1 {
+			fail(withCode(exitUsage, errors.New("--connections doit être supérieur ou égal à 1")))
 		}
-		if path == "" {
-			if files, ok := info["files"].([]interface{}); ok && len(files) > 0 {
-				if filePath, ok := files[0].(map[string]interface{})["path"].(string); ok {
-					path = filePath
-				}
-			}
+		if dldJobs < 1 {
+			fail(withCode(exitUsage, errors.New("--jobs doit être supérieur ou égal à 1")))
 		}
-
-		resp, err = http.Get("https://api.scw.iliad.fr/freetransfert/v2/files?transferKey=" + transfertKey[3] + "&path=" + path)
-		if err != nil {
-			red.Printf("Erreur (2ème fetch) : %s\n", err.Error())
-			return
+		if dldOutput == "-" && len(links) > 1 {
+			fail(withCode(exitUsage, errors.New("un seul lien peut être écrit sur la sortie standard")))
 		}
-		defer resp.Body.Close()
-
-		body, err = io.ReadAll(resp.Body)
-		if err != nil {
-			red.Printf("Erreur (2ème fetch) : %s\n", err.Error())
-			return
+		switch dldOnConflict {
+		case "", "ask", "skip", "overwrite", "rename":
+		default:
+			fail(withCode(exitUsage, errors.New("--on-conflict doit valoir ask, skip, overwrite ou rename")))
 		}
 
-		var url map[string]interface{}
-		if err := json.Unmarshal(body, &url); err != nil {
-			red.Printf("Erreur (2ème fetch) : %s\n", err.Error())
-			return
+		//Plusieurs transferts téléchargés en même temps ne peuvent pas afficher leurs progressbars
+		workers := dldJobs
+		if workers > len(links) {
+			workers = len(links)
 		}
+		dldQuiet = workers > 1
+		fmt.Println()
 
-		if url["error"] != nil || url["message"] != nil {
-			errMsg, ok := url["message"].(string)
-			if !ok {
-				errMsg = fmt.Sprintf("%v", url["message"])
-			}
-			red.Printf("Erreur (2ème fetch) : %s\n", errMsg)
-			return
+		results := make([]transferResult, len(links))
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := range jobs {
+					results[n] = downloadTransfer(cmd.Context(), links[n], vp)
+				}
+			}()
 		}
-
-		// Télécharger le fichier
-		resp, err = http.Get(url["url"].(string))
-		if err != nil {
-			red.Printf("Erreur lors du téléchargement : %s\n", err.Error())
-			return
+		for n := range links {
+			jobs <- n
 		}
-		defer resp.Body.Close()
-		fmt.Println()
-		filePath := fmt.Sprintf("%s/%s", vp.Get("cli.dld"), path)
-
-		//Vérifier si le fichier existe déjà
-		if _, err := os.Stat(filePath); err == nil {
-			var choice string
-			inquirer = &survey.Select{
-				Message: fmt.Sprintf("Le fichier %v existe déjà, que voulez-vous faire ?", path),
-				Options: []string{"Renommer le fichier téléchargé", "Renommer l'ancien fichier", "Remplacer", "Annuler"},
-			}
-			survey.AskOne(inquirer, &choice)
+		close(jobs)
+		wg.Wait()
 
-			if choice == "Renommer le fichier téléchargé" {
-				var input string
-				prompt := &survey.Input{
-					Message: "Nom du fichier :",
-				}
-				survey.AskOne(prompt, &input)
-				filePath = fmt.Sprintf("%s/%s", vp.Get("cli.dld"), input)
-			}
-			if choice == "Renommer l'ancien fichier" {
-				var input string
-				prompt := &survey.Input{
-					Message: "Nom du fichier :",
-				}
-				survey.AskOne(prompt, &input)
-				os.Rename(filePath, fmt.Sprintf("%s/%s", vp.Get("cli.dld"), input))
-			}
-			if choice == "Remplacer" {
-				//Yes or no
-				var danger bool
-				inquirer := &survey.Confirm{
-					Message: bred.Sprint("Êtes-vous sûr de vouloir remplacer le fichier ?\nAttention cet action est irréversible !"),
+		failed, downloaded := 0, 0
+		code := exitOK
+		for _, result := range results {
+			if result.Err != nil {
+				if failed++; code == exitOK {
+					code = exitCode(result.Err)
 				}
-				survey.AskOne(inquirer, &danger)
-				os.Remove(filePath)
 			}
-			if choice == "Annuler" {
-				return
+			downloaded += result.Files
+		}
+		if jsonOutput() {
+			if err := printDownloadJSON(results); err != nil {
+				fail(withCode(exitIO, err))
 			}
 		}
-		bar := progressbar.DefaultBytes(
-			resp.ContentLength,
-			green.Sprint("Téléchargement"),
-		)
-		out, _ := os.Create(filePath)
-		defer out.Close()
-
-		_, err = io.Copy(io.MultiWriter(out, bar), resp.Body)
-
-		if err != nil {
-			red.Printf("Erreur lors du téléchargement : %s\n", err.Error())
-			return
-		}
-		bar.Clear()
-
-		if isZip && vp.GetBool("cli.unzip") {
-			Unzip(filePath, vp.GetString("cli.dld"))
+		if len(results) > 1 && !jsonOutput() {
+			printSummary(results)
+		} else if results[0].Err != nil {
+			red.Printf("Erreur : %s\n", results[0].Err.Error())
 		}
 
 		//Vérifier si il faut afficher une notification et si il le faut avec du son.
-		if vp.GetBool("cli.notify") && vp.GetBool("cli.sound") {
-			beeep.Alert("FreeTransCLI", "Vos fichiers ont bien été téléchargés.", vp.GetString("cli.icon"))
-		} else if vp.GetBool("cli.notify") && !vp.GetBool("cli.sound") {
-			beeep.Notify("FreeTransCLI", "Vos fichiers ont bien été téléchargés.", vp.GetString("cli.icon"))
+		if downloaded > 0 {
+			if vp.GetBool("cli.notify") && vp.GetBool("cli.sound") {
+				beeep.Alert("FreeTransCLI", "Vos fichiers ont bien été téléchargés.", vp.GetString("cli.icon"))
+			} else if vp.GetBool("cli.notify") && !vp.GetBool("cli.sound") {
+				beeep.Notify("FreeTransCLI", "Vos fichiers ont bien été téléchargés.", vp.GetString("cli.icon"))
+			}
+		}
+		if failed > 0 {
+			os.Exit(code)
 		}
-
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(downloadCmd)
 
-	downloadCmd.SetUsageTemplate("Usage: freetranscli download [url]\n\n")
+	downloadCmd.SetUsageTemplate("Usage: freetranscli download [url...] [--from-file fichier] [--jobs N] [--zip] [--only motif] [--connections N] [-o fichier|dossier|-] [--on-conflict ask|skip|overwrite|rename]\n\n")
 	downloadCmd.Aliases = []string{"d", "dld", "dl", "down"}
-
+	downloadCmd.Flags().BoolVar(&dldZip, "zip", false, "Télécharger l'archive du transfert")
+	downloadCmd.Flags().StringVar(&dldOnConflict, "on-conflict", "", "Si un fichier existe déjà : ask, skip, overwrite ou rename")
+	downloadCmd.Flags().VarP(downloadOutputValue{}, "output", "o", "Fichier ou dossier de destination, - pour la sortie standard")
+	downloadCmd.Flags().StringVar(&dldOnly, "only", "", "Ne télécharger que les fichiers correspondant au motif")
+	downloadCmd.Flags().IntVar(&dldConnections, "connections", 1, "Nombre de connexions par fichier")
+	downloadCmd.Flags().StringVar(&dldFromFile, "from-file", "", "Fichier contenant les liens à télécharger (- pour l'entrée standard)")
+	downloadCmd.Flags().IntVar(&dldJobs, "jobs", 3, "Nombre de transferts téléchargés en même temps")
+	downloadCmd.Flags().BoolVar(&dldPassword, "password", false, "Demander le mot de passe du transfert")
+	downloadCmd.Flags().StringVar(&dldPasswordFile, "password-file", "", "Lire le mot de passe depuis un fichier")
 }
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"freetranscli/freetransfert"
+
+	"github.com/spf13/viper"
+)
+
+// Configuration minimale pour télécharger dans dir sans historique ni décompression
+func testDownloadConfig(dir string) *viper.Viper {
+	vp := viper.New()
+	vp.Set("cli.dld", dir)
+	vp.Set("cli.history", false)
+	vp.Set("cli.unzip", false)
+	return vp
+}
+
+// La taille déclarée à la création n'est qu'indicative : une archive déclarée avec la taille
+// de ses fichiers d'origine, sans taille, ou un fichier vide doivent se télécharger entièrement,
+// en un ou plusieurs flux
+func TestDownloadIgnoresDeclaredSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		declared int64
+		content  []byte
+	}{
+		{"taille des fichiers d'origine", 6000, bytes.Repeat([]byte("zip"), 100)},
+		{"sans taille", 0, bytes.Repeat([]byte("zip"), 100)},
+		{"fichier vide", 0, []byte{}},
+	}
+	for _, tt := range tests {
+		for _, connections := range []int{1, 4} {
+			api := newFakeAPI(t)
+			api.add("key0001", []freetransfert.File{{Path: "dossier.zip", Size: tt.declared}}, map[string][]byte{"dossier.zip": tt.content})
+			dir := t.TempDir()
+			dldConnections = connections
+			t.Cleanup(func() { dldConnections = 1 })
+
+			result := downloadTransfer(context.Background(), "https://transfert.free.fr/key0001", testDownloadConfig(dir))
+			if result.Err != nil {
+				t.Fatalf("%s, %d connexion(s) : %v", tt.name, connections, result.Err)
+			}
+			got, err := os.ReadFile(filepath.Join(dir, "dossier.zip"))
+			if err != nil || !bytes.Equal(got, tt.content) {
+				t.Fatalf("%s, %d connexion(s) : contenu téléchargé incorrect (%d octets, %v)", tt.name, connections, len(got), err)
+			}
+		}
+	}
+}
+
+// Une archive écrite dans le fichier donné à -o est conservée, même avec la décompression automatique
+func TestDownloadOutputFileKeepsArchive(t *testing.T) {
+	api := newFakeAPI(t)
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "a.txt", Mode: 0644, Size: 5})
+	tw.Write([]byte("hello"))
+	tw.Close()
+	gz.Close()
+	api.add("key0001", []freetransfert.File{{Path: "data.tar.gz"}}, map[string][]byte{"data.tar.gz": archive.Bytes()})
+
+	dir := t.TempDir()
+	vp := testDownloadConfig(dir)
+	vp.Set("cli.unzip", true)
+	vp.Set("cli.unzipfolder", defaultUnzipFolder)
+	dldOutput = filepath.Join(dir, "backup.tar.gz")
+	t.Cleanup(func() { dldOutput = "" })
+
+	result := downloadTransfer(context.Background(), "https://transfert.free.fr/key0001", vp)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	got, err := os.ReadFile(dldOutput)
+	if err != nil || !bytes.Equal(got, archive.Bytes()) {
+		t.Fatalf("archive non conservée dans %s : %v", dldOutput, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("seul backup.tar.gz devrait être écrit, trouvé %d éléments", len(entries))
+	}
+}
+
+func TestNumberedPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"photo.jpg", "arch.tar.gz", "arch.tar (1).gz", "data.TAR.ZST", "notes"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	os.Mkdir(filepath.Join(dir, "v1.2"), 0755)
+
+	tests := map[string]string{
+		"photo.jpg":    "photo (1).jpg",
+		"arch.tar.gz":  "arch (1).tar.gz",
+		"data.TAR.ZST": "data (1).TAR.ZST",
+		"notes":        "notes (1)",
+		"v1.2":         "v1.2 (1)",
+		"libre.txt":    "libre.txt",
+	}
+	for name, want := range tests {
+		if got := numberedPath(filepath.Join(dir, name)); got != filepath.Join(dir, want) {
+			t.Errorf("numberedPath(%s) = %s, attendu %s", name, filepath.Base(got), want)
+		}
+	}
+}
+
+// Un fichier .part déjà complet est terminé sans être téléchargé à nouveau
+func TestDownloadCompletePart(t *testing.T) {
+	api := newFakeAPI(t)
+	api.add("key0001", []freetransfert.File{{Path: "a.txt"}}, map[string][]byte{"a.txt": []byte("serveur")})
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "a.txt")
+	part, _ := partPaths(filePath)
+	//Contenu différent de même taille pour vérifier que le fichier n'est pas téléchargé à nouveau
+	os.WriteFile(part, []byte("partiel"), 0644)
+	writePartInfo(filePath, partInfo{TransferKey: "key0001", Path: "a.txt"})
+
+	result := downloadTransfer(context.Background(), "https://transfert.free.fr/key0001", testDownloadConfig(dir))
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if got, _ := os.ReadFile(filePath); string(got) != "partiel" {
+		t.Fatalf("le fichier a été téléchargé à nouveau : %q", got)
+	}
+	if _, err := os.Stat(part); !os.IsNotExist(err) {
+		t.Fatalf("le fichier .part n'a pas été supprimé : %v", err)
+	}
+}
+
+// Des téléchargements simultanés vers le même nom n'écrivent jamais dans le même fichier
+func TestResolveConflictConcurrent(t *testing.T) {
+	for _, policy := range []string{"rename", "overwrite", "skip"} {
+		t.Run(policy, func(t *testing.T) {
+			dldOnConflict = policy
+			t.Cleanup(func() { dldOnConflict = "" })
+			filePath := filepath.Join(t.TempDir(), "a.txt")
+
+			const workers = 8
+			paths := make(chan string, workers)
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					paths <- resolveConflict(filePath, "a.txt")
+				}()
+			}
+			wg.Wait()
+			close(paths)
+
+			seen := map[string]bool{}
+			for p := range paths {
+				if p == "" {
+					continue
+				}
+				if seen[p] {
+					t.Fatalf("%s attribué à deux téléchargements", filepath.Base(p))
+				}
+				seen[p] = true
+				releasePath(p)
+			}
+			want := workers
+			if policy == "skip" {
+				want = 1
+			}
+			if len(seen) != want {
+				t.Fatalf("%d chemins attribués, attendu %d", len(seen), want)
+			}
+			if len(reservedPaths) != 0 {
+				t.Fatalf("%d chemins encore réservés", len(reservedPaths))
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+
+	"freetranscli/freetransfert"
+)
+
+// Codes de sortie de FreeTransCLI, pour les scripts
+const (
+	exitOK       = 0
+	exitError    = 1 //Erreur générale
+	exitUsage    = 2 //Argument ou option invalide, réponse nécessaire sans question possible
+	exitNotFound = 3 //Fichier, transfert ou entrée de l'historique introuvable
+	exitNetwork  = 4 //Serveur injoignable, connexion interrompue
+	exitAPI      = 5 //Erreur renvoyée par l'API FreeTransfert
+	exitIO       = 6 //Lecture ou écriture impossible sur le disque, fichier endommagé
+)
+
+// Erreur associée à un code de sortie précis
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// Associer le code de sortie code à err
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// Code de sortie correspondant à la classe de l'erreur
+func exitCode(err error) int {
+	var coded *codedError
+	var apiErr *freetransfert.APIError
+	var netErr net.Error
+	var pathErr *fs.PathError
+	var linkErr *os.LinkError
+	var syscallErr *os.SyscallError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &coded):
+		return coded.code
+	case freetransfert.IsNotFound(err), errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	case errors.As(err, &apiErr):
+		return exitAPI
+	case errors.As(err, &netErr), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	case errors.Is(err, freetransfert.ErrCorrupted), errors.As(err, &pathErr), errors.As(err, &linkErr), errors.As(err, &syscallErr):
+		return exitIO
+	}
+	return exitError
+}
+
+// Afficher l'erreur et quitter avec le code de sortie correspondant
+func fail(err error) {
+	red.Printf("Erreur : %s\n", err.Error())
+	os.Exit(exitCode(err))
+}
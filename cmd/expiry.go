@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gen2brain/beeep"
+	"github.com/spf13/viper"
+)
+
+// Délai par défaut avant l'expiration d'un lien pour envoyer un rappel
+const defaultReminderWithin = 24 * time.Hour
+
+// Garder les entrées dont le lien expire dans moins de within et n'a pas encore expiré ni été supprimé
+func expiringHistory(entries []historyEntry, within time.Duration) []historyEntry {
+	now := time.Now()
+	var expiring []historyEntry
+	for _, entry := range entries {
+		if entry.RevokedAt != nil || entry.ExpiresAt == nil || entry.ExpiresAt.Before(now) || entry.ExpiresAt.After(now.Add(within)) {
+			continue
+		}
+		expiring = append(expiring, entry)
+	}
+	return expiring
+}
+
+// Prévenir par une notification des transferts téléversés qui vont bientôt expirer.
+// Chaque transfert ne fait l'objet que d'un seul rappel.
+func remindExpiring(vp *viper.Viper) {
+	if !vp.GetBool("cli.reminder") || !vp.GetBool("cli.history") {
+		return
+	}
+	within, err := parseDuration(vp.GetString("cli.reminderwithin"))
+	if err != nil {
+		within = defaultReminderWithin
+	}
+	entries, err := loadHistory()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	var reminded []historyEntry
+	for _, entry := range expiringHistory(entries, within) {
+		if entry.Direction != directionUpload || entry.RemindedAt != nil {
+			continue
+		}
+		entry.RemindedAt = &now
+		if err := updateHistory(entry); err != nil {
+			return
+		}
+		reminded = append(reminded, entry)
+	}
+	if len(reminded) == 0 {
+		return
+	}
+
+	var message string
+	if len(reminded) == 1 {
+		message = fmt.Sprintf("Le lien %s expire le %s.", reminded[0].URL, reminded[0].ExpiresAt.Local().Format("02/01/2006 à 15:04"))
+	} else {
+		message = fmt.Sprintf("%d liens expirent bientôt, 'freetranscli history expiring' pour les voir.", len(reminded))
+	}
+	yellow.Println(message)
+	if vp.GetBool("cli.notify") && vp.GetBool("cli.sound") {
+		beeep.Alert("FreeTransCLI", message, vp.GetString("cli.icon"))
+	} else if vp.GetBool("cli.notify") {
+		beeep.Notify("FreeTransCLI", message, vp.GetString("cli.icon"))
+	}
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/viper"
+)
+
+// Modèle par défaut du nom du dossier de décompression
+const defaultUnzipFolder = "freetransfert {date} {time}"
+
+// Taille maximale de la cible d'un lien symbolique dans une archive
+const maxLinkSize = 4096
+
+// Nom du dossier de décompression d'après un modèle, où {date}, {time}, {name} et {key}
+// sont remplacés par la date, l'heure, le nom de l'archive et la clé du transfert
+func unzipFolderName(template, archive, key string, now time.Time) string {
+	name := filepath.Base(archive)
+	for _, ext := range []string{".tar.gz", ".tar.zst", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.NewReplacer(
+		"{date}", now.Format("02_01_2006"),
+		"{time}", now.Format("15h04m05"),
+		"{name}", name,
+		"{key}", key,
+	).Replace(template))
+}
+
+// Chemin du dossier où décompresser l'archive d'un transfert dans dir, sans écraser un dossier existant
+func unzipFolder(vp *viper.Viper, dir, archive, key string) (string, error) {
+	template := vp.GetString("cli.unzipfolder")
+	if template == "" {
+		template = defaultUnzipFolder
+	}
+	folder, err := localPath(dir, unzipFolderName(template, archive, key, time.Now()))
+	if err != nil {
+		return "", fmt.Errorf("modèle de dossier de décompression invalide : %s", template)
+	}
+	return numberedPath(folder), nil
+}
+
+// Détecter le format d'une archive d'après son contenu : zip, tar, tar.gz, tar.zst ou "" si ce n'en est pas une
+func detectArchive(source string) string {
+	file, err := os.Open(source)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(file, head); err != nil {
+		return ""
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	format := formatTar
+	switch {
+	case bytes.Equal(head, []byte("PK\x03\x04")):
+		return formatZip
+	case head[0] == 0x1f && head[1] == 0x8b:
+		format = formatTarGz
+	case bytes.Equal(head, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		format = formatTarZst
+	}
+	//Un fichier gzip ou zstd n'est une archive que s'il contient un tar
+	reader, closeReader, err := tarDecompressor(file, format)
+	if err != nil {
+		return ""
+	}
+	defer closeReader()
+	if _, err := tar.NewReader(reader).Next(); err != nil {
+		return ""
+	}
+	return format
+}
+
+// Décompresser l'archive source au format format dans le dossier target puis supprimer l'archive
+func extractArchive(source, target, format string) error {
+	if format == formatZip {
+		return Unzip(source, target)
+	}
+	return Untar(source, target, format)
+}
+
+// Décompresser l'archive zip source dans le dossier target puis supprimer l'archive
+func Unzip(source, target string) error {
+	zipReader, err := zip.OpenReader(source)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	//La progressbar utilise la taille décompressée annoncée par l'archive
+	var size int64
+	for _, file := range zipReader.File {
+		if file.Mode().IsRegular() {
+			size += int64(file.UncompressedSize64)
+		}
+	}
+	bar := downloadBar(size, "Décompression")
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	//Les dates des dossiers sont appliquées à la fin, l'écriture des fichiers les modifiant
+	var dirs []archiveItem
+	for _, file := range zipReader.File {
+		item := archiveItem{Name: file.Name, Mode: file.Mode(), Modified: file.Modified}
+		if item.Mode.IsDir() {
+			dirs = append(dirs, item)
+		}
+		if err := extractZipFile(file, item, target, bar); err != nil {
+			return fmt.Errorf("%s : %w", file.Name, err)
+		}
+	}
+	restoreDirTimes(target, dirs)
+	bar.Finish()
+	return os.Remove(source)
+}
+
+// Extraire un élément de l'archive zip, la cible d'un lien symbolique étant stockée comme contenu
+func extractZipFile(file *zip.File, item archiveItem, target string, bar *progressbar.ProgressBar) error {
+	zippedFile, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer zippedFile.Close()
+
+	if item.Mode&os.ModeSymlink != 0 {
+		data, err := io.ReadAll(io.LimitReader(zippedFile, maxLinkSize+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxLinkSize {
+			data = nil
+		}
+		item.Link = string(data)
+	}
+	reader := progressbar.NewReader(zippedFile, bar)
+	return extractItem(target, item, &reader)
+}
+
+// Ouvrir le flux tar d'une archive tar, tar.gz ou tar.zst
+func tarDecompressor(r io.Reader, format string) (io.Reader, func(), error) {
+	switch format {
+	case formatTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gz, func() { gz.Close() }, nil
+	case formatTarZst:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	}
+	return r, func() {}, nil
+}
+
+// Décompresser l'archive tar source dans le dossier target puis supprimer l'archive
+func Untar(source, target, format string) error {
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	//La taille décompressée n'est pas connue d'avance, la progressbar suit la lecture de l'archive
+	bar := downloadBar(info.Size(), "Décompression")
+	reader := progressbar.NewReader(file, bar)
+	decompressed, closeReader, err := tarDecompressor(&reader, format)
+	if err != nil {
+		return err
+	}
+	defer closeReader()
+
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	tarReader := tar.NewReader(decompressed)
+	var dirs []archiveItem
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		item := archiveItem{Name: header.Name, Mode: header.FileInfo().Mode(), Modified: header.ModTime}
+		switch header.Typeflag {
+		case tar.TypeSymlink:
+			item.Link = header.Linkname
+		case tar.TypeLink:
+			item.HardLink = header.Linkname
+		case tar.TypeDir:
+			dirs = append(dirs, item)
+		}
+		if err := extractItem(target, item, tarReader); err != nil {
+			return fmt.Errorf("%s : %w", header.Name, err)
+		}
+	}
+	restoreDirTimes(target, dirs)
+	bar.Finish()
+	file.Close()
+	return os.Remove(source)
+}
+
+// Élément d'une archive à extraire
+type archiveItem struct {
+	Name     string      //Chemin dans l'archive
+	Mode     os.FileMode //Type et permissions
+	Modified time.Time
+	Link     string //Cible d'un lien symbolique
+	HardLink string //Élément de l'archive vers lequel pointe un lien physique
+}
+
+// Appliquer les dates de modification des dossiers une fois leur contenu extrait
+func restoreDirTimes(target string, dirs []archiveItem) {
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if dest, err := localPath(target, dirs[i].Name); err == nil {
+			os.Chtimes(dest, dirs[i].Modified, dirs[i].Modified)
+		}
+	}
+}
+
+// Extraire un élément de l'archive dans target en refusant les chemins qui en sortiraient
+func extractItem(target string, item archiveItem, content io.Reader) error {
+	//Le dossier racine d'une archive tar (./) correspond au dossier de décompression
+	if path.Clean(item.Name) == "." {
+		return nil
+	}
+	dest, err := localPath(target, strings.TrimSuffix(item.Name, "/"))
+	if err != nil {
+		return err
+	}
+	if !insideDir(target, filepath.Dir(dest)) {
+		return errors.New("chemin hors du dossier de décompression")
+	}
+	mode := item.Mode
+
+	switch {
+	case mode.IsDir():
+		if !insideDir(target, dest) {
+			return errors.New("chemin hors du dossier de décompression")
+		}
+		return os.MkdirAll(dest, mode.Perm()|0700)
+	case mode&os.ModeSymlink != 0:
+		return extractSymlink(item, target, dest)
+	case item.HardLink != "":
+		return extractHardLink(item, target, dest)
+	case !mode.IsRegular():
+		//Les fichiers spéciaux (périphériques, tubes…) ne sont pas extraits
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	//Ne pas écrire à travers un lien symbolique extrait plus tôt
+	if info, err := os.Lstat(dest); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		os.Remove(dest)
+	}
+
+	perm := mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	extractedFile, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(extractedFile, content)
+	if closeErr := extractedFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	//Restaurer les permissions (sans le masque de l'utilisateur) et la date de modification
+	os.Chmod(dest, perm)
+	return os.Chtimes(dest, item.Modified, item.Modified)
+}
+
+// Recréer un lien symbolique de l'archive s'il pointe dans le dossier de décompression
+func extractSymlink(item archiveItem, target, dest string) error {
+	link := filepath.FromSlash(item.Link)
+	if link == "" || filepath.IsAbs(link) {
+		yellow.Printf("\nLien symbolique %s ignoré : cible invalide\n", item.Name)
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(dest))
+	if err != nil {
+		return err
+	}
+	if !insideDir(target, filepath.Join(dir, link)) {
+		yellow.Printf("\nLien symbolique %s ignoré : il pointe hors du dossier de décompression\n", item.Name)
+		return nil
+	}
+	os.Remove(dest)
+	if err := os.Symlink(link, dest); err != nil {
+		yellow.Printf("\nLien symbolique %s ignoré : %s\n", item.Name, err)
+	}
+	return nil
+}
+
+// Recréer un lien physique vers un fichier déjà extrait de l'archive
+func extractHardLink(item archiveItem, target, dest string) error {
+	source, err := localPath(target, item.HardLink)
+	if err != nil || !insideDir(target, source) {
+		yellow.Printf("\nLien %s ignoré : il pointe hors du dossier de décompression\n", item.Name)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	os.Remove(dest)
+	return os.Link(source, dest)
+}
+
+// Vérifier que p reste dans le dossier root une fois résolus les liens symboliques déjà présents
+func insideDir(root, p string) bool {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return false
+	}
+	//Résoudre la partie existante du chemin, la suite ne contient pas encore de lien
+	existing, rest := p, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return false
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(realRoot, filepath.Join(real, rest))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Élément d'une archive de test
+type testEntry struct {
+	Name     string
+	Type     byte //tar.TypeReg, tar.TypeDir, tar.TypeSymlink ou tar.TypeLink
+	Mode     int64
+	Modified time.Time
+	Link     string
+	Content  string
+}
+
+// Écrire une archive tar contenant entries dans dir
+func writeTestTar(t *testing.T, dir string, entries []testEntry) string {
+	t.Helper()
+	source := filepath.Join(dir, "archive.tar")
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tw := tar.NewWriter(file)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.Name,
+			Typeflag: entry.Type,
+			Mode:     entry.Mode,
+			ModTime:  entry.Modified,
+			Linkname: entry.Link,
+			Size:     int64(len(entry.Content)),
+		}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+		if header.ModTime.IsZero() {
+			header.ModTime = time.Now()
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(entry.Content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return source
+}
+
+// Les éléments d'une archive ne doivent jamais être écrits hors du dossier de décompression,
+// et les dossiers et fichiers extraits gardent leurs permissions et leur date de modification
+func TestUntar(t *testing.T) {
+	modified := time.Date(2023, 5, 17, 10, 30, 0, 0, time.UTC)
+	noHardLink := func(t *testing.T, target string) {
+		if _, err := os.Lstat(filepath.Join(target, "hard")); !os.IsNotExist(err) {
+			t.Errorf("le lien physique ne devrait pas être créé : %v", err)
+		}
+	}
+	tests := []struct {
+		name    string
+		entries []testEntry
+		wantErr bool
+		check   func(t *testing.T, target string)
+	}{
+		{
+			name:    "chemin avec ..",
+			entries: []testEntry{{Name: "../outside/evil.txt", Type: tar.TypeReg, Content: "evil"}},
+			wantErr: true,
+		},
+		{
+			name:    "chemin avec .. au milieu",
+			entries: []testEntry{{Name: "a/../../outside/evil.txt", Type: tar.TypeReg, Content: "evil"}},
+			wantErr: true,
+		},
+		{
+			name:    "chemin absolu",
+			entries: []testEntry{{Name: "{root}/outside/evil.txt", Type: tar.TypeReg, Content: "evil"}},
+			wantErr: true,
+		},
+		{
+			name: "lien symbolique vers un dossier extérieur puis écriture à travers",
+			entries: []testEntry{
+				{Name: "link", Type: tar.TypeSymlink, Link: "../outside"},
+				{Name: "link/evil.txt", Type: tar.TypeReg, Content: "evil"},
+			},
+			check: func(t *testing.T, target string) {
+				info, err := os.Lstat(filepath.Join(target, "link"))
+				if err != nil || info.Mode()&os.ModeSymlink != 0 {
+					t.Errorf("link devrait être un dossier ordinaire : %v", err)
+				}
+			},
+		},
+		{
+			name: "lien symbolique absolu",
+			entries: []testEntry{
+				{Name: "link", Type: tar.TypeSymlink, Link: "{root}/outside"},
+				{Name: "link/evil.txt", Type: tar.TypeReg, Content: "evil"},
+			},
+		},
+		{
+			name: "lien symbolique à l'intérieur",
+			entries: []testEntry{
+				{Name: "a.txt", Type: tar.TypeReg, Content: "a"},
+				{Name: "link", Type: tar.TypeSymlink, Link: "a.txt"},
+			},
+			check: func(t *testing.T, target string) {
+				if link, err := os.Readlink(filepath.Join(target, "link")); err != nil || link != "a.txt" {
+					t.Errorf("lien %q, %v", link, err)
+				}
+			},
+		},
+		{
+			name:    "lien physique vers un fichier extérieur",
+			entries: []testEntry{{Name: "hard", Type: tar.TypeLink, Link: "../outside/secret.txt"}},
+			check:   noHardLink,
+		},
+		{
+			name:    "lien physique absolu",
+			entries: []testEntry{{Name: "hard", Type: tar.TypeLink, Link: "{root}/outside/secret.txt"}},
+			check:   noHardLink,
+		},
+		{
+			name: "dossiers imbriqués avec permissions et dates",
+			entries: []testEntry{
+				{Name: "a/", Type: tar.TypeDir, Mode: 0750, Modified: modified},
+				{Name: "a/b/", Type: tar.TypeDir, Mode: 0755, Modified: modified.Add(time.Hour)},
+				{Name: "a/b/c.txt", Type: tar.TypeReg, Mode: 0600, Modified: modified.Add(2 * time.Hour), Content: "c"},
+			},
+			check: func(t *testing.T, target string) {
+				want := map[string]struct {
+					mode     os.FileMode
+					modified time.Time
+				}{
+					"a":         {os.ModeDir | 0750, modified},
+					"a/b":       {os.ModeDir | 0755, modified.Add(time.Hour)},
+					"a/b/c.txt": {0600, modified.Add(2 * time.Hour)},
+				}
+				for name, w := range want {
+					info, err := os.Stat(filepath.Join(target, filepath.FromSlash(name)))
+					if err != nil {
+						t.Errorf("%s : %v", name, err)
+						continue
+					}
+					if info.Mode() != w.mode {
+						t.Errorf("%s : permissions %v, attendu %v", name, info.Mode(), w.mode)
+					}
+					if !info.ModTime().Equal(w.modified) {
+						t.Errorf("%s : date %v, attendu %v", name, info.ModTime(), w.modified)
+					}
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			outside := filepath.Join(root, "outside")
+			os.Mkdir(outside, 0755)
+			secret := filepath.Join(outside, "secret.txt")
+			os.WriteFile(secret, []byte("secret"), 0644)
+			target := filepath.Join(root, "target")
+
+			//{root} est remplacé par le dossier de test pour les chemins absolus
+			entries := make([]testEntry, len(tt.entries))
+			for i, entry := range tt.entries {
+				entry.Name = strings.ReplaceAll(entry.Name, "{root}", filepath.ToSlash(root))
+				entry.Link = strings.ReplaceAll(entry.Link, "{root}", filepath.ToSlash(root))
+				entries[i] = entry
+			}
+			err := Untar(writeTestTar(t, root, entries), target, formatTar)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erreur %v, attendue : %v", err, tt.wantErr)
+			}
+			if items, _ := os.ReadDir(outside); len(items) != 1 {
+				t.Errorf("%d éléments dans le dossier extérieur, attendu 1", len(items))
+			}
+			if data, _ := os.ReadFile(secret); string(data) != "secret" {
+				t.Errorf("le fichier extérieur a été modifié : %q", data)
+			}
+			if tt.check != nil {
+				tt.check(t, target)
+			}
+		})
+	}
+}
+
+// Un chemin avec .. dans une archive zip est refusé
+func TestUnzipRejectsParentPath(t *testing.T) {
+	root := t.TempDir()
+	source := filepath.Join(root, "archive.zip")
+	file, err := os.Create(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(file)
+	w, _ := zw.Create("../evil.txt")
+	w.Write([]byte("evil"))
+	zw.Close()
+	file.Close()
+
+	if err := Unzip(source, filepath.Join(root, "target")); err == nil {
+		t.Fatal("l'archive devrait être refusée")
+	}
+	if _, err := os.Stat(filepath.Join(root, "evil.txt")); !os.IsNotExist(err) {
+		t.Fatalf("evil.txt a été écrit hors du dossier de décompression : %v", err)
+	}
+}
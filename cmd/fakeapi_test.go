@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"freetranscli/freetransfert"
+)
+
+// Faux serveur de l'API FreeTransfert : il conserve les transferts en mémoire et
+// renvoie les tailles déclarées à la création, comme le fait l'API
+type fakeAPI struct {
+	*httptest.Server
+	mu        sync.Mutex
+	transfers map[string]*fakeTransfer
+	// Statut et corps renvoyés par DELETE s'ils sont définis, pour simuler une erreur de l'API
+	deleteStatus int
+	deleteBody   string
+}
+
+// Transfert enregistré par le faux serveur
+type fakeTransfer struct {
+	Files     []freetransfert.File //Fichiers tels que déclarés
+	Content   map[string][]byte
+	DeleteKey string
+	Deleted   bool
+}
+
+// Démarrer le faux serveur et y diriger le client partagé le temps du test
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{transfers: map[string]*fakeTransfer{}}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.handle))
+	previous := *client
+	client.BaseURL = api.URL
+	client.HTTPClient = api.Client()
+	t.Cleanup(func() {
+		api.Close()
+		*client = previous
+	})
+	return api
+}
+
+// Ajouter un transfert déjà téléversé
+func (api *fakeAPI) add(key string, declared []freetransfert.File, content map[string][]byte) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.transfers[key] = &fakeTransfer{Files: declared, Content: content, DeleteKey: "del-" + key}
+}
+
+// Transfert enregistré sous key
+func (api *fakeAPI) transfer(key string) *fakeTransfer {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.transfers[key]
+}
+
+func (api *fakeAPI) handle(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/transfers":
+		var in struct {
+			Files []freetransfert.File `json:"files"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, `{"error":"bad_request"}`, http.StatusBadRequest)
+			return
+		}
+		key := fmt.Sprintf("key%04d", len(api.transfers)+1)
+		api.transfers[key] = &fakeTransfer{Files: in.Files, Content: map[string][]byte{}, DeleteKey: "del-" + key}
+		var targets []freetransfert.UploadTarget
+		for _, file := range in.Files {
+			targets = append(targets, freetransfert.UploadTarget{Path: file.Path, UploadURL: api.URL + "/upload/" + key + "/" + neturl.PathEscape(file.Path)})
+		}
+		json.NewEncoder(w).Encode(freetransfert.CreatedTransfer{Key: key, Files: targets, DeleteKey: "del-" + key})
+
+	case r.Method == http.MethodPut && len(parts) == 3 && parts[0] == "upload":
+		transfer := api.transfers[parts[1]]
+		path, _ := neturl.PathUnescape(parts[2])
+		//Comme les adresses d'envoi signées, refuser un contenu sans Content-Length
+		if r.ContentLength < 0 {
+			w.WriteHeader(http.StatusLengthRequired)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if transfer == nil || err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		transfer.Content[path] = data
+
+	case len(parts) == 2 && parts[0] == "transfers":
+		transfer := api.transfers[parts[1]]
+		if transfer == nil || transfer.Deleted {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not_found","message":"transfert introuvable"}`))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			var in struct {
+				DeleteKey string `json:"deleteKey"`
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			switch {
+			case api.deleteStatus != 0:
+				w.WriteHeader(api.deleteStatus)
+				w.Write([]byte(api.deleteBody))
+			case in.DeleteKey != transfer.DeleteKey:
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				transfer.Deleted = true
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+		json.NewEncoder(w).Encode(freetransfert.Transfer{Key: parts[1], Files: transfer.Files})
+
+	case r.URL.Path == "/files":
+		key, path := r.URL.Query().Get("transferKey"), r.URL.Query().Get("path")
+		json.NewEncoder(w).Encode(freetransfert.SignedURL{URL: api.URL + "/raw/" + key + "/" + neturl.PathEscape(path)})
+
+	case len(parts) == 3 && parts[0] == "raw":
+		transfer := api.transfers[parts[1]]
+		path, _ := neturl.PathUnescape(parts[2])
+		if transfer == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		//ServeContent gère les requêtes partielles et l'en-tête Content-Range
+		http.ServeContent(w, r, path, time.Time{}, bytes.NewReader(transfer.Content[path]))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// Diriger les dossiers de configuration, de données et de cache vers un dossier temporaire
+func setupTestDirs(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir+"/config")
+	t.Setenv("XDG_DATA_HOME", dir+"/data")
+	t.Setenv("XDG_CACHE_HOME", dir+"/cache")
+	setupDirs()
+	return dir
+}
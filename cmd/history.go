@@ -1,58 +1,384 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"freetranscli/freetransfert"
+
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+)
+
+var (
+	histSince     string
+	histType      string
+	histDirection string
+	histJSON      bool
+	histCSV       bool
+	histWithin    string
 )
 
 // historyCmd represents the history command
 var historyCmd = &cobra.Command{
 	Use:   "history",
-	Short: "Affiche l'historique des fichiers téléversés",
-	Long:  `Affiche l'historique des fichiers téléversés avec la date, le chemin du fichier au moment du téléversement et l'url FreeTransfert`,
+	Short: "Affiche l'historique des fichiers téléversés et téléchargés",
+	Long: `
+Affiche l'historique des fichiers téléversés et téléchargés avec la date, les chemins des fichiers et l'url FreeTransfert.
+
+Commandes :
+  list            Lister l'historique (commande par défaut)
+  search <texte>  Rechercher dans les urls, clés et chemins des fichiers
+  expiring        Lister les liens qui expirent bientôt (--within 48h par défaut)
+  open <id>       Afficher à nouveau le QR code et copier l'adresse du transfert
+  download <id>   Télécharger à nouveau le transfert
+  reupload <id>   Téléverser à nouveau les fichiers, par exemple lorsque le lien a expiré.
+                  Le chiffrement, le message, les destinataires et la notification sont repris,
+                  un transfert protégé demande --password ou --password-file
+
+Options :
+  --since <durée|date>  N'afficher que les entrées depuis une durée (48h, 7d) ou une date (2006-01-02)
+  --type <type>         N'afficher qu'un type d'entrée (file, folder, files, archive)
+  --direction <sens>    N'afficher que les téléversements (upload) ou les téléchargements (download)
+  --json                Afficher l'historique au format JSON
+  --csv                 Afficher l'historique au format CSV
+
+Alias : hist`,
 	Run: func(cmd *cobra.Command, args []string) {
+		historyListCmd.Run(cmd, args)
+	},
+}
 
-		//Executer la commande open os.TempDir() + "/FreeTransCLI_temp/historic.yaml
-		err := exec.Command("open", historicfile).Run()
+var historyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lister l'historique",
+	Run: func(cmd *cobra.Command, args []string) {
+		showHistory("")
+	},
+}
 
+var historySearchCmd = &cobra.Command{
+	Use:   "search <texte>",
+	Short: "Rechercher dans l'historique",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		showHistory(strings.Join(args, " "))
+	},
+}
+
+var historyExpiringCmd = &cobra.Command{
+	Use:   "expiring",
+	Short: "Lister les liens qui vont bientôt expirer",
+	Run: func(cmd *cobra.Command, args []string) {
+		within, err := parseDuration(histWithin)
 		if err != nil {
-			fmt.Println(err)
+			fail(withCode(exitUsage, fmt.Errorf("--within invalide : %s (exemples : 48h, 7d)", histWithin)))
+		}
+		showHistoryWith("", func(entries []historyEntry) []historyEntry {
+			return expiringHistory(entries, within)
+		})
+	},
+}
+
+var historyOpenCmd = &cobra.Command{
+	Use:   "open <id>",
+	Short: "Afficher à nouveau le QR code et copier l'adresse d'un transfert",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := mustFindHistory(args[0])
+		vp := readConfig()
+		shareLink(vp, entry.URL)
+	},
+}
+
+var historyDownloadCmd = &cobra.Command{
+	Use:   "download <id>",
+	Short: "Télécharger à nouveau un transfert de l'historique",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := mustFindHistory(args[0])
+		vp := readConfig()
+		result := downloadTransfer(cmd.Context(), entry.URL, vp)
+		if result.Err != nil {
+			fail(result.Err)
+		}
+		green.Printf("%d fichier(s) téléchargé(s) dans %s\n", result.Files, vp.GetString("cli.dld"))
+	},
+}
+
+var historyReuploadCmd = &cobra.Command{
+	Use:   "reupload <id>",
+	Short: "Téléverser à nouveau les fichiers d'une entrée de l'historique",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		entry := mustFindHistory(args[0])
+		if len(entry.Paths) == 0 {
+			fail(withCode(exitNotFound, errors.New("aucun chemin n'est enregistré pour cette entrée")))
+		}
+		for _, path := range entry.Paths {
+			if path == "-" {
+				fail(withCode(exitUsage, errors.New("ce transfert a été lu depuis l'entrée standard, il ne peut pas être téléversé à nouveau")))
+			}
+			if _, err := os.Stat(path); err != nil {
+				fail(withCode(exitNotFound, fmt.Errorf("%s n'est plus disponible sur cet ordinateur", path)))
+			}
+		}
+		if err := reuploadOptions(entry); err != nil {
+			fail(err)
 		}
+		uploadCmd.SetContext(cmd.Context())
+		uploadCmd.Run(uploadCmd, append([]string(nil), entry.Paths...))
 	},
 }
 
-func historic(url string, path string, filetype string, size string) {
-	//Afficher la date et l'heure au format DD/MM/YYYY HH:MM:SS
-	vp := viper.New()
-	vp.SetConfigName("historic")
-	vp.SetConfigType("yaml")
-	vp.AddConfigPath(tempDir)
-	err := vp.ReadInConfig()
+// Reprendre pour un nouveau téléversement les options du transfert d'origine : chiffrement,
+// message, destinataires et notification. Un transfert protégé demande à nouveau un mot de passe.
+func reuploadOptions(entry *historyEntry) error {
+	if entry.Protected && !upPassword && upPasswordFile == "" {
+		return withCode(exitUsage, errors.New("ce transfert était protégé par un mot de passe, relancez la commande avec --password ou --password-file"))
+	}
+	secret, err := freetransfert.ParseEncryptionKey(entry.URL)
 	if err != nil {
-		fmt.Println(err)
+		return err
+	}
+	//Les options sont données comme en ligne de commande pour ne pas être proposées à nouveau
+	flags := uploadCmd.Flags()
+	values := map[string]string{
+		"encrypt":            strconv.FormatBool(secret != nil),
+		"message":            entry.Message,
+		"to":                 strings.Join(entry.Recipients, ","),
+		"notify-on-download": strconv.FormatBool(entry.Notify),
 	}
+	for name, value := range values {
+		if err := flags.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	now := time.Now()
-	dateTimeString := now.Format("02/01/2006 15:04:05")
+// Trouver une entrée de l'historique ou quitter avec une erreur
+func mustFindHistory(id string) *historyEntry {
+	entry, err := findHistory(id)
+	if err != nil {
+		fail(err)
+	}
+	return entry
+}
 
-	vp.Set(dateTimeString+".path", path)
-	vp.Set(dateTimeString+".url", url)
-	vp.Set(dateTimeString+".filetype", filetype)
-	vp.Set(dateTimeString+".size", size)
+// Afficher les entrées de l'historique correspondant à la recherche et aux filtres
+func showHistory(search string) {
+	showHistoryWith(search, nil)
+}
 
-	err = vp.WriteConfig()
+// Comme showHistory, en appliquant en plus le filtre keep s'il n'est pas nil
+func showHistoryWith(search string, keep func([]historyEntry) []historyEntry) {
+	entries, err := loadHistory()
+	if err != nil {
+		fail(withCode(exitIO, fmt.Errorf("impossible de lire l'historique : %w", err)))
+	}
+	entries, err = filterHistory(entries, search)
 	if err != nil {
-		red.Println("Erreur : Impossible d'écrire la configuration\n", err)
-		os.Exit(0)
+		fail(withCode(exitUsage, err))
+	}
+	if keep != nil {
+		entries = keep(entries)
+	}
+
+	switch {
+	case histJSON || jsonOutput():
+		writeHistoryJSON(entries)
+	case histCSV:
+		writeHistoryCSV(entries)
+	case len(entries) == 0:
+		yellow.Println("Aucune entrée dans l'historique")
+	default:
+		printHistory(entries)
+	}
+}
+
+// Garder les entrées contenant search et correspondant aux options --since, --type et --direction
+func filterHistory(entries []historyEntry, search string) ([]historyEntry, error) {
+	var since time.Time
+	if histSince != "" {
+		var err error
+		since, err = parseSince(histSince)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if histDirection != "" && histDirection != directionUpload && histDirection != directionDownload {
+		return nil, fmt.Errorf("--direction doit valoir %s ou %s", directionUpload, directionDownload)
+	}
+
+	search = strings.ToLower(search)
+	var filtered []historyEntry
+	for _, entry := range entries {
+		if !since.IsZero() && entry.CreatedAt.Before(since) {
+			continue
+		}
+		if histType != "" && !strings.EqualFold(entry.Type, histType) {
+			continue
+		}
+		if histDirection != "" && entry.Direction != histDirection {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(historyText(entry)), search) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered, nil
+}
+
+// Texte dans lequel history search recherche
+func historyText(entry historyEntry) string {
+	fields := append([]string{entry.ID, entry.TransferKey, entry.URL, entry.Type, entry.Message}, entry.Recipients...)
+	return strings.Join(append(fields, entry.Paths...), "\n")
+}
+
+// Convertir une durée (48h, 7d) ou une date (2006-01-02, 02/01/2006) en date de début
+func parseSince(value string) (time.Time, error) {
+	if d, err := parseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
 	}
+	for _, layout := range []string{"2006-01-02", "02/01/2006", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("--since invalide : %s (exemples : 48h, 7d, 2006-01-02)", value)
+}
+
+// Comme time.ParseDuration, en acceptant aussi un nombre de jours (7d)
+func parseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("durée invalide : %s", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// Afficher l'historique sous forme de tableau
+func printHistory(entries []historyEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tEXPIRATION\tSENS\tTYPE\tTAILLE\tURL\tCHEMINS")
+	for _, entry := range entries {
+		expires := "-"
+		if entry.RevokedAt != nil {
+			expires = "supprimé"
+		} else if entry.ExpiresAt != nil {
+			expires = entry.ExpiresAt.Local().Format("02/01/2006 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			entry.ID,
+			entry.CreatedAt.Local().Format("02/01/2006 15:04"),
+			expires,
+			entry.Direction,
+			entry.Type,
+			readableSize(entry.Size),
+			entry.URL,
+			strings.Join(entry.Paths, ", "),
+		)
+	}
+	w.Flush()
+}
+
+// Entrée de l'historique affichée en JSON, sans le jeton de suppression qui permettrait
+// à quiconque lit la sortie de supprimer le transfert
+type historyOutput struct {
+	ID          string     `json:"id"`
+	Direction   string     `json:"direction"`
+	TransferKey string     `json:"transferKey,omitempty"`
+	URL         string     `json:"url"`
+	Paths       []string   `json:"paths"`
+	Type        string     `json:"type,omitempty"`
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	Message     string     `json:"message,omitempty"`
+	Recipients  []string   `json:"recipients,omitempty"`
+	Notify      bool       `json:"notifyOnDownload,omitempty"`
+	Protected   bool       `json:"passwordProtected,omitempty"`
+}
+
+// Afficher l'historique au format JSON
+func writeHistoryJSON(entries []historyEntry) {
+	output := make([]historyOutput, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, historyOutput{
+			ID:          entry.ID,
+			Direction:   entry.Direction,
+			TransferKey: entry.TransferKey,
+			URL:         entry.URL,
+			Paths:       entry.Paths,
+			Type:        entry.Type,
+			Size:        entry.Size,
+			CreatedAt:   entry.CreatedAt,
+			UpdatedAt:   entry.UpdatedAt,
+			ExpiresAt:   entry.ExpiresAt,
+			RevokedAt:   entry.RevokedAt,
+			Message:     entry.Message,
+			Recipients:  entry.Recipients,
+			Notify:      entry.Notify,
+			Protected:   entry.Protected,
+		})
+	}
+	printJSON(output)
+}
+
+// Afficher l'historique au format CSV
+func writeHistoryCSV(entries []historyEntry) {
+	w := csv.NewWriter(dataOut)
+	w.Write([]string{"id", "direction", "type", "size", "createdAt", "expiresAt", "transferKey", "url", "paths", "message", "recipients"})
+	for _, entry := range entries {
+		expires := ""
+		if entry.ExpiresAt != nil {
+			expires = entry.ExpiresAt.Format(time.RFC3339)
+		}
+		w.Write([]string{
+			entry.ID,
+			entry.Direction,
+			entry.Type,
+			strconv.FormatInt(entry.Size, 10),
+			entry.CreatedAt.Format(time.RFC3339),
+			expires,
+			entry.TransferKey,
+			entry.URL,
+			strings.Join(entry.Paths, ";"),
+			entry.Message,
+			strings.Join(entry.Recipients, ";"),
+		})
+	}
+	w.Flush()
 }
 
 func init() {
 	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyListCmd, historySearchCmd, historyExpiringCmd, historyOpenCmd, historyDownloadCmd, historyReuploadCmd)
+
+	historyCmd.Aliases = []string{"hist"}
+	historyListCmd.Aliases = []string{"ls"}
+	historySearchCmd.Aliases = []string{"find"}
+
+	historyReuploadCmd.Flags().BoolVar(&upPassword, "password", false, "Protéger le nouveau transfert par un mot de passe")
+	historyReuploadCmd.Flags().StringVar(&upPasswordFile, "password-file", "", "Lire le mot de passe depuis un fichier")
+	historyExpiringCmd.Flags().StringVar(&histWithin, "within", "48h", "Délai avant expiration (48h, 7d)")
 
+	flags := historyCmd.PersistentFlags()
+	flags.StringVar(&histSince, "since", "", "N'afficher que les entrées depuis une durée (48h, 7d) ou une date")
+	flags.StringVar(&histType, "type", "", "N'afficher qu'un type d'entrée")
+	flags.StringVar(&histDirection, "direction", "", "upload ou download")
+	flags.BoolVar(&histJSON, "json", false, "Afficher l'historique au format JSON")
+	flags.BoolVar(&histCSV, "csv", false, "Afficher l'historique au format CSV")
 }
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"freetranscli/freetransfert"
+
+	"github.com/spf13/viper"
+)
+
+// Sens d'un transfert enregistré dans l'historique
+const (
+	directionUpload   = "upload"
+	directionDownload = "download"
+)
+
+// Une entrée de l'historique, enregistrée sur une ligne JSON de historic.jsonl.
+// Une entrée modifiée est réécrite à la fin du fichier avec le même identifiant, la dernière version l'emporte.
+type historyEntry struct {
+	ID          string     `json:"id"`
+	Direction   string     `json:"direction"`
+	TransferKey string     `json:"transferKey,omitempty"`
+	URL         string     `json:"url"`
+	Paths       []string   `json:"paths"`
+	Type        string     `json:"type,omitempty"`
+	Size        int64      `json:"size"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	// Date du rappel envoyé avant l'expiration du lien
+	RemindedAt *time.Time `json:"remindedAt,omitempty"`
+	// Jeton de suppression reçu à la création du transfert et date de sa suppression
+	DeleteKey string     `json:"deleteKey,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	// Message et destinataires indiqués au téléversement
+	Message    string   `json:"message,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+	Notify     bool     `json:"notifyOnDownload,omitempty"`
+	// Transfert protégé par un mot de passe (le mot de passe n'est pas enregistré)
+	Protected bool `json:"passwordProtected,omitempty"`
+}
+
+// Empêche deux écritures simultanées dans l'historique
+var historyMu sync.Mutex
+
+// Générer un identifiant court et unique pour une entrée de l'historique
+func newHistoryID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Enregistrer une nouvelle entrée dans l'historique
+func historic(entry historyEntry) {
+	now := time.Now()
+	if entry.ID == "" {
+		entry.ID = newHistoryID()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	if err := appendHistory(entry); err != nil {
+		red.Println("Erreur : Impossible d'écrire l'historique\n", err)
+	}
+}
+
+// Enregistrer une nouvelle version d'une entrée existante de l'historique
+func updateHistory(entry historyEntry) error {
+	entry.UpdatedAt = time.Now()
+	return appendHistory(entry)
+}
+
+// Ajouter une ligne à la fin du fichier d'historique
+func appendHistory(entry historyEntry) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	//L'historique contient les jetons de suppression, il n'est lisible que par l'utilisateur
+	file, err := os.OpenFile(historicfile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Lire l'historique, dans l'ordre de création des entrées
+func loadHistory() ([]historyEntry, error) {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	file, err := os.Open(historicfile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		entries []historyEntry
+		index   = map[string]int{}
+	)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry historyEntry
+		//Ignorer les lignes abîmées, par exemple après une écriture interrompue
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.ID == "" {
+			continue
+		}
+		if n, ok := index[entry.ID]; ok {
+			entries[n] = entry
+			continue
+		}
+		index[entry.ID] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Trouver une entrée de l'historique par son identifiant ou le début de celui-ci
+func findHistory(id string) (*historyEntry, error) {
+	entries, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	var found *historyEntry
+	for n := range entries {
+		if entries[n].ID == id {
+			return &entries[n], nil
+		}
+		if id != "" && strings.HasPrefix(entries[n].ID, id) {
+			if found != nil {
+				return nil, fmt.Errorf("plusieurs entrées commencent par %s, précisez l'identifiant", id)
+			}
+			found = &entries[n]
+		}
+	}
+	if found == nil {
+		return nil, withCode(exitNotFound, fmt.Errorf("aucune entrée %s dans l'historique", id))
+	}
+	return found, nil
+}
+
+// Importer l'ancien historique historic.yaml, indexé par date, dans historic.jsonl.
+// L'ancien fichier est renommé pour ne pas être importé une seconde fois.
+func migrateHistory(legacy string) error {
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+	vp := viper.New()
+	vp.SetConfigFile(legacy)
+	vp.SetConfigType("yaml")
+	if err := vp.ReadInConfig(); err != nil {
+		return err
+	}
+
+	var entries []historyEntry
+	for date, value := range vp.AllSettings() {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		created, err := time.ParseInLocation("02/01/2006 15:04:05", date, time.Local)
+		if err != nil {
+			continue
+		}
+		entry := historyEntry{
+			ID:        newHistoryID(),
+			Direction: directionUpload,
+			URL:       fmt.Sprint(fields["url"]),
+			Type:      fmt.Sprint(fields["filetype"]),
+			Size:      parseReadableSize(fmt.Sprint(fields["size"])),
+			CreatedAt: created,
+			UpdatedAt: created,
+		}
+		if path, ok := fields["path"].(string); ok {
+			entry.Paths = []string{path}
+		}
+		if key, err := freetransfert.ParseKey(entry.URL); err == nil {
+			entry.TransferKey = key
+		}
+		entries = append(entries, entry)
+	}
+
+	//Conserver l'ordre chronologique des anciennes entrées
+	sort.Slice(entries, func(a, b int) bool { return entries[a].CreatedAt.Before(entries[b].CreatedAt) })
+	for _, entry := range entries {
+		if err := appendHistory(entry); err != nil {
+			return err
+		}
+	}
+	return os.Rename(legacy, legacy+".migrated")
+}
+
+// Convertir une taille produite par readableSize en octets, 0 si elle n'est pas reconnue
+func parseReadableSize(s string) int64 {
+	units := map[string]float64{"o": 1, "Ko": 1000, "Mo": 1000 * 1000, "Go": 1000 * 1000 * 1000}
+	var (
+		value float64
+		unit  string
+	)
+	if _, err := fmt.Sscanf(s, "%f %s", &value, &unit); err != nil {
+		return 0
+	}
+	return int64(value * units[unit])
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"freetranscli/freetransfert"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+var infoJSON bool
+
+// Informations d'un transfert affichées avec --json
+type infoOutput struct {
+	Key           string     `json:"key"`
+	Files         []infoFile `json:"files"`
+	TotalSize     int64      `json:"totalSize"`
+	Zip           bool       `json:"zip"`
+	ZipSize       int64      `json:"zipSize,omitempty"`
+	ExpiresAt     *time.Time `json:"expiresAt,omitempty"`
+	DownloadCount *int       `json:"downloadCount,omitempty"`
+	Protected     bool       `json:"passwordProtected"`
+}
+
+type infoFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// infoCmd represents the info command
+var infoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Afficher le contenu d'un transfert FreeTransfert sans le télécharger",
+	Long: `
+Afficher le nom et la taille des fichiers d'un transfert, la disponibilité d'une archive zip,
+la date d'expiration et le nombre de téléchargements lorsqu'ils sont fournis.
+Exemple : freetranscli info https://transfert.free.fr/2kxQZv
+
+Options :
+  --json  Afficher les informations au format JSON
+
+Alias : i, inspect`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			var input string
+			prompt := &survey.Input{
+				Message: "Lien FreeTransCLI :",
+			}
+			if err := ask(prompt, &input); err != nil {
+				fail(withCode(exitUsage, errors.New("aucun lien FreeTransfert indiqué")))
+			}
+			//Retirer les guillemets si il y en a
+			args = append(args, strings.ReplaceAll(input, "'", ""))
+		}
+
+		key, err := freetransfert.ParseKey(args[0])
+		if err != nil {
+			fail(withCode(exitUsage, err))
+		}
+		info, err := client.GetTransfer(cmd.Context(), key)
+		if err != nil {
+			fail(err)
+		}
+
+		if infoJSON || jsonOutput() {
+			output := infoOutput{
+				Key:           key,
+				Files:         []infoFile{},
+				TotalSize:     info.TotalSize(),
+				Zip:           info.Zip != nil && info.Zip.Path != "",
+				ExpiresAt:     info.ExpiresAt,
+				DownloadCount: info.DownloadCount,
+				Protected:     info.PasswordProtected,
+			}
+			if output.Zip {
+				output.ZipSize = info.Zip.Size
+			}
+			for _, file := range info.Files {
+				output.Files = append(output.Files, infoFile{Path: file.Path, Size: file.Size})
+			}
+			printJSON(output)
+			return
+		}
+
+		fmt.Println("Transfert", bmagenta.Sprint(key))
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+		for _, file := range info.Files {
+			fmt.Fprintf(w, "  %s\t%s\n", file.Path, readableSize(file.Size))
+		}
+		w.Flush()
+		fmt.Printf("\nTaille totale : %s (%d fichier(s))\n", readableSize(info.TotalSize()), len(info.Files))
+		if info.Zip != nil && info.Zip.Path != "" {
+			fmt.Println("Archive zip :", green.Sprintf("disponible (%s)", readableSize(info.Zip.Size)))
+		} else {
+			fmt.Println("Archive zip :", yellow.Sprint("indisponible"))
+		}
+		if info.PasswordProtected {
+			fmt.Println("Protégé par un mot de passe :", yellow.Sprint("oui"))
+		}
+		if info.ExpiresAt != nil {
+			fmt.Println("Expire le :", info.ExpiresAt.Local().Format("02/01/2006 15:04"))
+		}
+		if info.DownloadCount != nil {
+			fmt.Println("Téléchargements :", *info.DownloadCount)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+
+	infoCmd.SetUsageTemplate("Usage: freetranscli info [url] [--json]\n\n")
+	infoCmd.Aliases = []string{"i", "inspect"}
+	infoCmd.Flags().BoolVar(&infoJSON, "json", false, "Afficher les informations au format JSON")
+}
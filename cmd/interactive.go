@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/AlecAivazis/survey/v2"
+	"golang.org/x/term"
+)
+
+var (
+	// Répondre oui aux confirmations et ne poser aucune question (--yes)
+	assumeYes bool
+	// Ne poser aucune question (--non-interactive)
+	nonInteractive bool
+)
+
+// Erreur renvoyée lorsqu'une réponse est nécessaire mais que les questions sont désactivées
+var errNonInteractive = withCode(exitUsage, errors.New("une réponse est nécessaire mais les questions sont désactivées, précisez-la avec les options de la commande"))
+
+// Erreur renvoyée lorsqu'une action irréversible doit être confirmée sans question possible
+var errConfirmRequired = withCode(exitUsage, errors.New("confirmation nécessaire, relancez la commande avec --yes"))
+
+// Indiquer si FreeTransCLI peut poser des questions : ni --yes, ni --non-interactive,
+// et l'entrée standard est un terminal
+func interactive() bool {
+	return !assumeYes && !nonInteractive && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// Poser une question, ou renvoyer errNonInteractive si les questions sont désactivées
+func ask(prompt survey.Prompt, response interface{}, opts ...survey.AskOpt) error {
+	if !interactive() {
+		return errNonInteractive
+	}
+	return survey.AskOne(prompt, response, opts...)
+}
+
+// Demander confirmation avant une action irréversible, acceptée d'office avec --yes
+func confirmAction(message string) (bool, error) {
+	if assumeYes {
+		return true, nil
+	}
+	var confirm bool
+	if err := ask(&survey.Confirm{Message: message}, &confirm); err != nil {
+		if errors.Is(err, errNonInteractive) {
+			return false, errConfirmRequired
+		}
+		return false, err
+	}
+	return confirm, nil
+}
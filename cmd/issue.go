@@ -38,19 +38,22 @@ Si vous n'avez pas de navigateur web l'url s'affichera dans la console.
 Si vous n'avez pas de compte GitHub vous pouvez en créé un gratuitement sur https://github.com/signup`,
 
 	Run: func(cmd *cobra.Command, args []string) {
+		if !interactive() {
+			fail(errNonInteractive)
+		}
 		//Demander le titre
 		var title string
 		prompt := &survey.Input{
 			Message: "Titre de l'issue:",
 		}
-		survey.AskOne(prompt, &title)
+		ask(prompt, &title)
 
 		//Demander la description avec survey en multi ligne
 		var description string
 		multiline := &survey.Multiline{
 			Message: "Description de l'issue:",
 		}
-		survey.AskOne(multiline, &description)
+		ask(multiline, &description)
 
 		//Ouvrir le site d'issue avec le titre et la description
 		openbrowser("https://github.com/el2zay/freetranscli/issues/new?title=" + title + "&body=" + description)
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-colorable"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+var (
+	// Format de sortie des commandes : text ou json (--output)
+	outputFormat string
+	// Sortie des résultats lisibles par une machine, la sortie standard d'origine
+	dataOut io.Writer = os.Stdout
+)
+
+// Indiquer si les résultats doivent être affichés en JSON
+func jsonOutput() bool {
+	return outputFormat == "json"
+}
+
+// Appliquer --output. En JSON, la sortie standard est réservée au résultat :
+// les messages, questions et QR codes passent sur la sortie d'erreur comme les progressbars.
+// C'est aussi le cas pour les options --json des commandes et lorsque download écrit le fichier
+// sur la sortie standard (-o -). setupOutput est appelée avant Conf, qui peut afficher des messages.
+func setupOutput() error {
+	switch outputFormat {
+	case "", "text", "json":
+	default:
+		return withCode(exitUsage, errors.New("--output doit valoir text ou json"))
+	}
+	if jsonOutput() && dldOutput == "-" {
+		return withCode(exitUsage, errors.New("--output json ne peut pas être combiné à -o -"))
+	}
+	if reservedStdout() {
+		dataOut = os.Stdout
+		os.Stdout = os.Stderr
+		color.Output = colorable.NewColorableStderr()
+		color.NoColor = color.NoColor || !term.IsTerminal(int(os.Stderr.Fd()))
+	}
+	return nil
+}
+
+// Indiquer si la sortie standard est réservée aux données : --output json, info --json,
+// history --json ou --csv, ou download -o -
+func reservedStdout() bool {
+	return jsonOutput() || infoJSON || histJSON || histCSV || dldOutput == "-"
+}
+
+// Écrire v en JSON sur la sortie standard
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(dataOut)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Indiquer si les progressbars peuvent être affichées : en JSON, seulement si la sortie d'erreur est un terminal
+func showProgress() bool {
+	return !jsonOutput() || term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// Créer une progressbar d'octets, silencieuse si elle ne peut pas être affichée
+func bytesBar(size int64, label string) *progressbar.ProgressBar {
+	if !showProgress() {
+		return progressbar.DefaultBytesSilent(size, label)
+	}
+	return progressbar.DefaultBytes(size, green.Sprint(label))
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// Les options qui écrivent des données réservent la sortie standard avant que Conf n'affiche ses messages
+func TestSetupOutputReservesStdout(t *testing.T) {
+	stdout, colorOut := os.Stdout, color.Output
+	t.Cleanup(func() {
+		os.Stdout, color.Output, dataOut = stdout, colorOut, stdout
+		outputFormat, infoJSON, histJSON, histCSV = "text", false, false, false
+	})
+
+	for name, flag := range map[string]*bool{"info --json": &infoJSON, "history --json": &histJSON, "history --csv": &histCSV} {
+		os.Stdout, dataOut = stdout, stdout
+		*flag = true
+		if err := setupOutput(); err != nil {
+			t.Fatal(err)
+		}
+		if dataOut != stdout || os.Stdout != os.Stderr {
+			t.Fatalf("%s doit réserver la sortie standard aux données", name)
+		}
+		*flag = false
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"freetranscli/freetransfert"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// Nombre de tentatives pour chaque partie d'un téléchargement en parallèle
+const chunkRetries = 3
+
+// Écrit à partir d'une position donnée du fichier et fait avancer la progressbar
+type offsetWriter struct {
+	file   *os.File
+	offset int64
+	bar    *progressbar.ProgressBar
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.file.WriteAt(p, w.offset)
+	w.offset += int64(n)
+	w.bar.Add(n)
+	return n, err
+}
+
+// Télécharger le fichier de url dans dest en utilisant plusieurs connexions.
+// Renvoie freetransfert.ErrRangeUnsupported avant d'avoir écrit quoi que ce soit si le serveur ne le permet pas.
+func parallelDownload(ctx context.Context, url *freetransfert.SignedURL, dest string, connections int, label string) error {
+	// Vérifier que le serveur accepte les requêtes partielles et obtenir la taille du fichier
+	probe, err := client.DownloadRange(ctx, url, 0, 0, "")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	etag, size := probe.ETag, probe.Size
+	if size <= 0 {
+		return freetransfert.ErrRangeUnsupported
+	}
+
+	// Préallouer le fichier
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := out.Truncate(size); err != nil {
+		return err
+	}
+
+	bar := downloadBar(size, label)
+
+	chunk := (size + int64(connections) - 1) / int64(connections)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		written  int64 //Octets écrits par l'ensemble des parties
+	)
+	for start := int64(0); start < size; start += chunk {
+		end := start + chunk - 1
+		if end >= size {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			n, err := downloadChunk(ctx, url, etag, out, start, end, bar)
+			mu.Lock()
+			defer mu.Unlock()
+			written += n
+			if err != nil && firstErr == nil {
+				firstErr = err
+				cancel()
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	bar.Clear()
+
+	//Le fichier a été préalloué, seule la somme des octets reçus indique s'il est complet
+	if written != size {
+		return fmt.Errorf("téléchargement incomplet : %s reçus sur %s", readableSize(written), readableSize(size))
+	}
+	if err := out.Sync(); err != nil {
+		return err
+	}
+	return checkDownload(out, size, etag)
+}
+
+// Télécharger les octets start à end dans out, en reprenant là où la partie s'est arrêtée en cas d'erreur.
+// Chaque requête exige l'ETag du fichier sondé : un fichier modifié entre-temps arrête le téléchargement
+// au lieu de mélanger deux versions. Renvoie le nombre d'octets écrits, end-start+1 si la partie est complète.
+func downloadChunk(ctx context.Context, url *freetransfert.SignedURL, etag string, out *os.File, start, end int64, bar *progressbar.ProgressBar) (int64, error) {
+	w := &offsetWriter{file: out, offset: start, bar: bar}
+	var err error
+	for attempt := 1; attempt <= chunkRetries; attempt++ {
+		var content *freetransfert.Content
+		content, err = client.DownloadRange(ctx, url, w.offset, end, etag)
+		if err == nil {
+			_, err = io.Copy(w, content)
+			content.Close()
+			if err == nil && w.offset != end+1 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		if err == nil || ctx.Err() != nil || errors.Is(err, freetransfert.ErrFileChanged) {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+	if err != nil {
+		return w.offset - start, fmt.Errorf("partie %d-%d : %w", start, end, err)
+	}
+	return w.offset - start, nil
+}
+
+// Vérifier le contenu des size premiers octets du fichier téléchargé si l'ETag est une empreinte MD5
+func checkDownload(file *os.File, size int64, etag string) error {
+	etag = strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+	if len(etag) != md5.Size*2 {
+		return nil
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return nil
+	}
+	hash := md5.New()
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, size)); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(sum, etag) {
+		return fmt.Errorf("le contenu téléchargé ne correspond pas à l'empreinte du serveur")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"freetranscli/freetransfert"
+)
+
+func TestCheckDownload(t *testing.T) {
+	content := []byte("contenu téléchargé en plusieurs parties")
+	file, err := os.Create(filepath.Join(t.TempDir(), "fichier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	file.Write(content)
+	sum := md5.Sum(content)
+	size := int64(len(content))
+
+	if err := checkDownload(file, size, `"`+hex.EncodeToString(sum[:])+`"`); err != nil {
+		t.Fatalf("empreinte correcte refusée : %v", err)
+	}
+	if err := checkDownload(file, size, `"`+hex.EncodeToString(make([]byte, md5.Size))+`"`); err == nil {
+		t.Fatal("une empreinte différente doit être refusée")
+	}
+	//Un ETag qui n'est pas une empreinte MD5 ne permet aucune vérification
+	if err := checkDownload(file, size, `W/"5f3a-17c"`); err != nil {
+		t.Fatalf("ETag opaque refusé : %v", err)
+	}
+}
+
+// Un fichier modifié sur le serveur après la sonde arrête le téléchargement en plusieurs parties
+// sans nouvelle tentative
+func TestParallelDownloadFileChanged(t *testing.T) {
+	content := bytes.Repeat([]byte("v1"), 500)
+	var refused int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//La sonde voit la version v1, les parties demandent v1 alors que le fichier est passé en v2
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-Match") != "" {
+			w.Header().Set("ETag", `"v2"`)
+			atomic.AddInt32(&refused, 1)
+		}
+		http.ServeContent(w, r, "fichier", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+
+	dest := filepath.Join(t.TempDir(), "fichier.part")
+	err := parallelDownload(context.Background(), &freetransfert.SignedURL{URL: server.URL}, dest, 4, "Téléchargement")
+	if !errors.Is(err, freetransfert.ErrFileChanged) {
+		t.Fatalf("erreur %v, attendu %v", err, freetransfert.ErrFileChanged)
+	}
+	//Les autres parties peuvent être annulées avant leur requête, mais aucune n'est retentée
+	if n := atomic.LoadInt32(&refused); n < 1 || n > 4 {
+		t.Fatalf("%d requêtes refusées, attendu au plus une par partie", n)
+	}
+}
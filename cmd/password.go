@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+)
+
+// Variable d'environnement contenant le mot de passe d'un transfert, pour les scripts
+const passwordEnv = "FREETRANSCLI_PASSWORD"
+
+// Obtenir le mot de passe d'un transfert depuis un fichier, la variable FREETRANSCLI_PASSWORD
+// ou à défaut en le demandant sans l'afficher. confirm demande de le saisir deux fois.
+func readPassword(file string, confirm bool) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		password := strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return "", errors.New("le fichier de mot de passe est vide")
+		}
+		return password, nil
+	}
+	if password := os.Getenv(passwordEnv); password != "" {
+		return password, nil
+	}
+
+	if !interactive() {
+		return "", withCode(exitUsage, errors.New("mot de passe nécessaire : utilisez --password-file ou la variable "+passwordEnv))
+	}
+
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	var password string
+	err := ask(&survey.Password{Message: "Mot de passe du transfert :"}, &password)
+	if err != nil {
+		return "", err
+	}
+	if password == "" {
+		return "", errors.New("aucun mot de passe saisi")
+	}
+	if confirm {
+		var again string
+		err := ask(&survey.Password{Message: "Confirmez le mot de passe :"}, &again)
+		if err != nil {
+			return "", err
+		}
+		if again != password {
+			return "", errors.New("les mots de passe ne correspondent pas")
+		}
+	}
+	return password, nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+
+	"freetranscli/freetransfert"
+)
+
+// Informations enregistrées à côté d'un fichier .part pour pouvoir reprendre son téléchargement
+type partInfo struct {
+	TransferKey string `json:"transferKey"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ETag        string `json:"etag"`
+}
+
+// Chemin du fichier partiel et de ses informations pour un fichier en cours de téléchargement
+func partPaths(filePath string) (string, string) {
+	return filePath + ".part", filePath + ".part.json"
+}
+
+// Lire les informations du téléchargement partiel de filePath
+func readPartInfo(filePath string) (*partInfo, error) {
+	_, sidecar := partPaths(filePath)
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return nil, err
+	}
+	var info partInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Enregistrer les informations du téléchargement partiel de filePath
+func writePartInfo(filePath string, info partInfo) error {
+	_, sidecar := partPaths(filePath)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sidecar, data, 0644)
+}
+
+// Taille déjà téléchargée et ETag à utiliser pour reprendre le téléchargement de filePath,
+// 0 si le fichier partiel n'existe pas ou ne correspond pas au fichier demandé
+func resumeOffset(filePath, key, remote string, size int64) (int64, string) {
+	part, _ := partPaths(filePath)
+	info, err := readPartInfo(filePath)
+	if err != nil || info.TransferKey != key || info.Path != remote || info.Size != size {
+		return 0, ""
+	}
+	//Si le fichier partiel dépasse le fichier du serveur, celui-ci refuse la reprise et renvoie tout le fichier
+	stat, err := os.Stat(part)
+	if err != nil {
+		return 0, ""
+	}
+	return stat.Size(), info.ETag
+}
+
+// Terminer un téléchargement partiel : renommer le fichier .part et supprimer ses informations
+func finishPart(filePath string) error {
+	part, sidecar := partPaths(filePath)
+	if err := os.Rename(part, filePath); err != nil {
+		return err
+	}
+	os.Remove(sidecar)
+	return nil
+}
+
+// Terminer le téléchargement d'un fichier chiffré : déchiffrer le fichier .part vers filePath.
+// Si le contenu a été modifié, le fichier déchiffré et le fichier .part sont supprimés.
+func finishEncryptedPart(filePath string, secret []byte) error {
+	part, sidecar := partPaths(filePath)
+	in, err := os.Open(part)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	reader, err := freetransfert.NewDecryptReader(in, secret)
+	if err == nil {
+		var out *os.File
+		out, err = os.Create(filePath)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, reader)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		os.Remove(filePath)
+		if errors.Is(err, freetransfert.ErrCorrupted) {
+			os.Remove(part)
+			os.Remove(sidecar)
+		}
+		return err
+	}
+
+	in.Close()
+	os.Remove(part)
+	os.Remove(sidecar)
+	return nil
+}
@@ -1,14 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"runtime"
 	"time"
 
+	"freetranscli/freetransfert"
+
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,29 +26,35 @@ var (
 	cyan           = color.New(color.FgCyan)
 	configFilePath string
 	configDir      string
+	dataDir        string //Historique et autres données durables
+	cacheDir       string //Fichiers temporaires
+	historicfile   string
 	dldPath        string
 	home           string
+	// Client de l'API FreeTransfert partagé par les commandes
+	client = freetransfert.NewClient()
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use: "freetranscli",
+	//La configuration est chargée une fois les options globales lues
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := setupOutput(); err != nil {
+			fail(err)
+		}
+		Conf()
+	},
 }
 
 func Conf() {
-	//Définir le répertoire de configuration selon l'OS
-	if runtime.GOOS == "windows" {
-		configDir = os.Getenv("APPDATA") + "/freetranscli" //C:\Users\%USERNAME%\AppData\Roaming\freetranscli
-		home = os.Getenv("USERPROFILE")                    //C:\Users\%USERNAME%
-
-	} else {
-		configDir = os.Getenv("HOME") + "/.config/freetranscli" //~/.config/freetranscli
-		home = os.Getenv("HOME")                                //~
+	//Définir les répertoires de configuration, de données et de cache selon l'OS
+	setupDirs()
+	client.TempDir = cacheDir
 
-	}
-	// créer le répertoire de configuration s'il n'existe pas
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		os.Mkdir(configDir, 0777)
+	//Récupérer les fichiers des anciens emplacements (dossier temporaire, ancien dossier de configuration)
+	if err := migrateLegacyFiles(); err != nil {
+		yellow.Println("Impossible de déplacer les anciens fichiers de FreeTransCLI :", err)
 	}
 
 	// créer le fichier de configuration s'il n'existe pas
@@ -55,14 +63,9 @@ func Conf() {
 		os.Create(configFilePath)
 	}
 
-	//Si il n'y a pas de dossier temporaire, on en crée un
-	if _, err := os.Stat(tempDir); os.IsNotExist(err) {
-		os.Mkdir(tempDir, 0777)
-	}
-
-	//Créer un fichier historic.yaml
-	if _, err := os.Stat(tempDir + "/historic.yaml"); os.IsNotExist(err) {
-		os.Create(tempDir + "/historic.yaml")
+	//Importer l'ancien historique historic.yaml s'il existe
+	if err := migrateHistory(dataDir + "/historic.yaml"); err != nil {
+		yellow.Println("Impossible d'importer l'ancien historique :", err)
 	}
 	vp := viper.New()
 
@@ -87,6 +90,11 @@ func Conf() {
 		"cli.lastmsg":   "",
 		"cli.notfound":  true,
 		"cli.unzip":     true,
+		// Nom du dossier de décompression, voir unzipFolderName
+		"cli.unzipfolder": defaultUnzipFolder,
+		// Rappel par notification avant l'expiration des liens téléversés
+		"cli.reminder":       false,
+		"cli.reminderwithin": "24h",
 	}
 
 	// Lit la configuration existante
@@ -95,8 +103,7 @@ func Conf() {
 	vp.AddConfigPath(configDir)
 	err = vp.ReadInConfig()
 	if err != nil {
-		red.Println(err)
-		os.Exit(0)
+		fail(withCode(exitIO, err))
 	}
 
 	// Vérifie si toutes les clés de configuration existent et ajoute les valeurs par défaut si nécessaire
@@ -109,8 +116,7 @@ func Conf() {
 	// Écrit la configuration
 	err = vp.WriteConfig()
 	if err != nil {
-		red.Println("Impossible d'écrire la configuration :", err)
-		os.Exit(0)
+		fail(withCode(exitIO, fmt.Errorf("impossible d'écrire la configuration : %w", err)))
 	}
 
 	var (
@@ -118,61 +124,94 @@ func Conf() {
 		difference     = currentDate.Sub(vp.GetTime("cli.lastmsg"))
 		currentVersion = "0.0.0"
 	)
-	//Obtenir la dernière version publiée
+	//Obtenir la dernière version publiée, sans bloquer FreeTransCLI hors connexion
 	resp, err := http.Get("https://api.github.com/repos/el2zay/freetranscli/releases/latest")
 	if err != nil {
-		yellow.Println("Impossible de récupérer la dernière version publiée\n", err)
-	}
-
-	//Lire le body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		red.Println(err)
-		os.Exit(0)
-	}
+		if vp.GetBool("cli.update") && interactive() {
+			yellow.Println("Impossible de récupérer la dernière version publiée\n", err)
+		}
+	} else {
+		//Lire le body
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			body = nil
+		}
 
-	//Convertir en json
-	var data map[string]interface{}
-	json.Unmarshal(body, &data)
+		//Convertir en json
+		var data map[string]interface{}
+		json.Unmarshal(body, &data)
+		latest, _ := data["tag_name"].(string)
 
-	//Vérifier que si la version actuelle est inférieure à la dernière version publiée et que l'utilisateur a activé l'affichage du message et que le dernier message a été affiché il y a plus de 12 heures
-	if currentVersion < data["tag_name"].(string) && vp.GetBool("cli.update") && difference.Hours() >= 12 {
-		fmt.Print("Une nouvelle version est disponible ", bred.Sprint(currentVersion), " → ", bgreen.Sprint(data["tag_name"]), "\n'freetranscli set' pour activer les mises à jour automatiques \n\n")
+		//Vérifier que si la version actuelle est inférieure à la dernière version publiée et que l'utilisateur a activé l'affichage du message et que le dernier message a été affiché il y a plus de 12 heures
+		if latest != "" && currentVersion < latest && vp.GetBool("cli.update") && difference.Hours() >= 12 {
+			fmt.Print("Une nouvelle version est disponible ", bred.Sprint(currentVersion), " → ", bgreen.Sprint(latest), "\n'freetranscli set' pour activer les mises à jour automatiques \n\n")
 
-		vp.Set("cli.lastmsg", currentDate)
+			vp.Set("cli.lastmsg", currentDate)
+		}
 	}
 	//Ecrire dans la configuration
 	err = vp.WriteConfig()
 	if err != nil {
-		red.Println("Impossible d'écrire la configuration\n", err)
-		os.Exit(0)
+		fail(withCode(exitIO, fmt.Errorf("impossible d'écrire la configuration : %w", err)))
 	}
 
+	//Prévenir des liens qui vont bientôt expirer
+	remindExpiring(vp)
+
+}
+
+// Lire le fichier de configuration
+func readConfig() *viper.Viper {
+	vp := viper.New()
+	vp.SetConfigName("config")
+	vp.SetConfigType("yaml")
+	vp.AddConfigPath(configDir)
+	err := vp.ReadInConfig()
+	if err != nil {
+		fail(withCode(exitIO, err))
+	}
+	return vp
 }
 
 // Tout le temps executer au démarrage
 func Execute() {
-	Conf()
-	err := rootCmd.Execute()
+	err := rootCmd.ExecuteContext(context.Background())
 	if err != nil {
-		os.Exit(1)
+		//Commande ou option inconnue
+		os.Exit(exitUsage)
 	}
 }
 
 func init() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "Répondre oui aux confirmations et ne poser aucune question")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Ne poser aucune question")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Format de sortie : text ou json")
 	rootCmd.SetHelpTemplate(`
 Usage:
       {{.Use}} [commande]
 
 Commandes:
+      delete/rm     Supprimer un transfert que vous avez téléversé
       download/d    Télécharger un fichier depuis FreeTransfert grâce à l'url du fichier
       help          Aide à propos d'une commande
-      history       Affiche l'historique des fichiers téléversés
+      history       Affiche l'historique des fichiers téléversés et téléchargés
+      info/i        Affiche le contenu d'un transfert sans le télécharger
       issue         Ouvre une issue sur GitHub
       set/config    Paramétrer FreeTransCLI
       uninstall     Désinstaller FreeTransCLI
       upload/u      Téléverser un fichier sur FreeTransfert grâce au chemin du fichier
+
+Options globales:
+      -y, --yes           Répondre oui aux confirmations et ne poser aucune question
+      --non-interactive   Ne poser aucune question (automatique si l'entrée n'est pas un terminal)
+      --output json       Afficher le résultat de upload et download en JSON sur la sortie standard,
+                          les messages et progressbars passent sur la sortie d'erreur
+
+Codes de sortie:
+      0 succès, 1 erreur, 2 option invalide ou réponse nécessaire, 3 introuvable,
+      4 réseau, 5 API FreeTransfert, 6 lecture ou écriture sur le disque
 `)
 
 }
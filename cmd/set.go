@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/AlecAivazis/survey/v2/terminal"
@@ -16,8 +18,6 @@ import (
 )
 
 var (
-	tempDir        = os.TempDir() + "FreeTransCLI_temp"
-	historicfile   = os.TempDir() + "FreeTransCLI_temp/historic.yaml"
 	unzipchoice    string
 	notifychoice   string
 	soundchoice    string
@@ -27,6 +27,7 @@ var (
 	histchoice     string
 	updatechoice   string
 	notfoundchoice string
+	reminderchoice string
 	inquirer       *survey.Select
 )
 
@@ -46,8 +47,11 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 		vp.AddConfigPath(configDir)
 		err := vp.ReadInConfig()
 		if err != nil {
-			red.Println(err)
-			os.Exit(0)
+			fail(withCode(exitIO, err))
+		}
+		//Le menu de configuration ne peut être utilisé que dans un terminal
+		if !interactive() {
+			fail(errNonInteractive)
 		}
 
 		for {
@@ -100,6 +104,12 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 				notfoundchoice = "Activer la suggestion de chemin similaire en cas d'erreur"
 			}
 
+			if vp.GetBool("cli.reminder") {
+				reminderchoice = "Désactiver le rappel avant l'expiration des liens"
+			} else {
+				reminderchoice = "Activer le rappel avant l'expiration des liens"
+			}
+
 			var choice string
 			if os.Getenv("DISPLAY") != "" || os.Getenv("DISPLAY") != ":0" || runtime.GOOS == "windows" {
 				inquirer = &survey.Select{
@@ -107,9 +117,11 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 					Options: []string{
 						"Choisir le dossier de téléchargement par défaut",
 						unzipchoice,
+						"Choisir le nom du dossier de décompression",
 						notifychoice,
 						soundchoice,
 						iconchoice,
+						reminderchoice,
 						clipchoice,
 						qrchoice,
 						histchoice,
@@ -119,7 +131,7 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 						red.Sprint("Réinitialiser la configuration"),
 						red.Sprint("Désinstaller FreeTransCLI"),
 					},
-					PageSize: 13,
+					PageSize: 15,
 				}
 			} else {
 				inquirer = &survey.Select{
@@ -127,6 +139,7 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 					Options: []string{
 						"Choisir le dossier de téléchargement par défaut",
 						unzipchoice,
+						"Choisir le nom du dossier de décompression",
 						clipchoice,
 						qrchoice,
 						histchoice,
@@ -136,7 +149,7 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 						red.Sprint("Réinitialiser la configuration"),
 						red.Sprint("Désinstaller FreeTransCLI"),
 					},
-					PageSize: 10,
+					PageSize: 11,
 				}
 			}
 			err := survey.AskOne(inquirer, &choice)
@@ -231,6 +244,26 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 			if choice == unzipchoice {
 				vp.Set("cli.unzip", !vp.GetBool("cli.unzip"))
 			}
+			if choice == "Choisir le nom du dossier de décompression" {
+				var template string
+				prompt := &survey.Input{
+					Message: "Nom du dossier ({date}, {time}, {name} et {key} sont remplacés) :",
+					Default: vp.GetString("cli.unzipfolder"),
+				}
+				survey.AskOne(prompt, &template)
+				template = strings.TrimSpace(template)
+				if template == "" {
+					red.Println("Erreur : Aucun nom spécifié")
+					continue
+				}
+				//Le dossier doit rester dans le dossier de téléchargement
+				if _, err := localPath(".", unzipFolderName(template, "archive.zip", "cle", time.Now())); err != nil {
+					red.Println("Erreur : Le nom du dossier ne doit pas sortir du dossier de téléchargement")
+					continue
+				}
+				vp.Set("cli.unzipfolder", template)
+				green.Println("Les archives seront décompressées dans :", unzipFolderName(template, "archive.zip", "cle", time.Now()))
+			}
 			if choice == notifychoice {
 				vp.Set("cli.notify", !vp.GetBool("cli.notify"))
 			}
@@ -298,6 +331,10 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 				}
 			}
 
+			if choice == reminderchoice {
+				vp.Set("cli.reminder", !vp.GetBool("cli.reminder"))
+			}
+
 			if choice == clipchoice {
 				vp.Set("cli.clipboard", !vp.GetBool("cli.clipboard"))
 			}
@@ -367,8 +404,7 @@ Paramétrer et personnaliser le client, pour le moment vous pouvez changer le do
 			}
 			err = vp.WriteConfig()
 			if err != nil {
-				red.Println("Erreur : Impossible d'écrire la configuration\n", err)
-				os.Exit(0)
+				fail(withCode(exitIO, fmt.Errorf("impossible d'écrire la configuration : %w", err)))
 			}
 		}
 	},
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"freetranscli/freetransfert"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+// Options de transfert de la commande upload
+var (
+	upExpires string
+	upMessage string
+	upTo      string
+	upNotify  bool
+)
+
+// Nombre de jours correspondant aux durées proposées
+var availabilityLabels = map[string]int{
+	"1 jour":   1,
+	"7 jours":  7,
+	"30 jours": 30,
+}
+
+// Remplir opts avec --expires, --message, --to et --notify-on-download.
+// Si aucune de ces options n'est donnée, proposer de les saisir lorsque les questions sont possibles.
+func askTransferOptions(cmd *cobra.Command, opts *freetransfert.TransferOptions) error {
+	var err error
+	if upExpires != "" {
+		opts.Availability, err = freetransfert.ParseAvailability(upExpires)
+		if err != nil {
+			return err
+		}
+	}
+	opts.Message = strings.TrimSpace(upMessage)
+	opts.Recipients, err = freetransfert.ParseRecipients(upTo)
+	if err != nil {
+		return err
+	}
+	opts.NotifyOnDownload = upNotify
+
+	flags := cmd.Flags()
+	given := flags.Changed("expires") || flags.Changed("message") || flags.Changed("to") || flags.Changed("notify-on-download")
+	if !given && interactive() {
+		if err := promptTransferOptions(opts); err != nil {
+			return err
+		}
+	}
+	return opts.Validate()
+}
+
+// Demander la durée, le message et les destinataires du transfert
+func promptTransferOptions(opts *freetransfert.TransferOptions) error {
+	var more bool
+	err := ask(&survey.Confirm{
+		Message: "Choisir la durée, ajouter un message ou des destinataires ?",
+		Default: false,
+	}, &more)
+	if err != nil || !more {
+		return err
+	}
+
+	var availability string
+	err = ask(&survey.Select{
+		Message: "Durée de disponibilité :",
+		Options: []string{"1 jour", "7 jours", "30 jours"},
+		Default: "7 jours",
+	}, &availability)
+	if err != nil {
+		return err
+	}
+	opts.Availability = availabilityLabels[availability]
+
+	err = ask(&survey.Input{
+		Message: "Message (facultatif) :",
+	}, &opts.Message, survey.WithValidator(func(ans interface{}) error {
+		if n := utf8.RuneCountInString(ans.(string)); n > freetransfert.MaxMessageLength {
+			return fmt.Errorf("message trop long : %d caractères (maximum %d)", n, freetransfert.MaxMessageLength)
+		}
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+	opts.Message = strings.TrimSpace(opts.Message)
+
+	var to string
+	err = ask(&survey.Input{
+		Message: "Destinataires, séparés par des virgules (facultatif) :",
+	}, &to, survey.WithValidator(func(ans interface{}) error {
+		_, err := freetransfert.ParseRecipients(ans.(string))
+		return err
+	}))
+	if err != nil {
+		return err
+	}
+	opts.Recipients, _ = freetransfert.ParseRecipients(to)
+
+	return ask(&survey.Confirm{
+		Message: "Être prévenu à chaque téléchargement ?",
+		Default: false,
+	}, &opts.NotifyOnDownload)
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -21,8 +22,7 @@ var uninstallCmd = &cobra.Command{
 		vp.AddConfigPath(configDir)
 		err := vp.ReadInConfig()
 		if err != nil {
-			red.Println(err)
-			os.Exit(0)
+			fail(withCode(exitIO, err))
 		}
 		//Définir ftcSize comme une variable globale
 		var ftcSize int64
@@ -35,14 +35,16 @@ var uninstallCmd = &cobra.Command{
 		} else {
 			ftcSize = config.Size()
 		}
-		//Vérifier la taille du dossier de configuration
-		temp, err := os.Stat(tempDir)
-		//Si il y a une erreur ne pas le calculer
-		if err != nil {
-			ftcSize += 0
-		} else {
-			// Sinon ajouter la taille du dossier de configuration au calcul
-			ftcSize += temp.Size()
+		//Vérifier la taille des dossiers de données et de cache
+		for _, dir := range []string{dataDir, cacheDir} {
+			temp, err := os.Stat(dir)
+			//Si il y a une erreur ne pas le calculer
+			if err != nil {
+				ftcSize += 0
+			} else {
+				// Sinon ajouter la taille du dossier au calcul
+				ftcSize += temp.Size()
+			}
 		}
 		//Vérifier la taille du fichier ftc
 		ftc, err := os.Stat(path)
@@ -56,15 +58,20 @@ var uninstallCmd = &cobra.Command{
 
 		fmt.Println("• FreeTransCLI ne fonctionne pas correctement ? " + bmagenta.Sprint("Ouvrez une issue !\n"))
 		fmt.Println("Estimation de l'espace disque qui sera libéré : " + bmagenta.Sprintf(readableSize(ftcSize)))
-		var choice string
-		inquirer = &survey.Select{
-			Message: "Désinstaller FreeTransCLI ? ",
-			Options: []string{
-				bgreen.Sprintf("Non"),
-				red.Sprintf("Oui"),
-			},
+		//La désinstallation est confirmée d'office avec --yes
+		choice := red.Sprintf("Oui")
+		if !assumeYes {
+			inquirer = &survey.Select{
+				Message: "Désinstaller FreeTransCLI ? ",
+				Options: []string{
+					bgreen.Sprintf("Non"),
+					red.Sprintf("Oui"),
+				},
+			}
+			if err := ask(inquirer, &choice); err != nil {
+				fail(errConfirmRequired)
+			}
 		}
-		survey.AskOne(inquirer, &choice)
 		if choice == bgreen.Sprintf("Non") {
 			bgreen.Println("Merci pour votre confiance !")
 		}
@@ -72,25 +79,24 @@ var uninstallCmd = &cobra.Command{
 			if _, err := os.Stat(configDir); !os.IsNotExist(err) {
 				err := os.RemoveAll(configDir)
 				if err != nil {
-					red.Println("Erreur lors de la suppression du dossier de configuration : ", err, "PATH : ", configDir)
-					os.Exit(0)
+					fail(withCode(exitIO, fmt.Errorf("suppression du dossier de configuration %s impossible : %w", configDir, err)))
 				}
-				if _, err := os.Stat(tempDir); !os.IsNotExist(err) {
-					err := os.RemoveAll(tempDir)
-					if err != nil {
-						red.Println("Erreur lors de la suppression du dossier temporaire :", err, "PATH : ", tempDir)
+				for _, dir := range []string{dataDir, cacheDir} {
+					if _, err := os.Stat(dir); !os.IsNotExist(err) {
+						err := os.RemoveAll(dir)
+						if err != nil {
+							red.Println("Erreur lors de la suppression du dossier :", err, "PATH : ", dir)
 
+						}
 					}
 				}
 				path, err := exec.LookPath("freetranscli")
 				if err != nil {
-					red.Println("FreeTransCLI n'a pas été détecté sur votre système.")
-					os.Exit(0)
+					fail(withCode(exitNotFound, errors.New("FreeTransCLI n'a pas été détecté sur votre système")))
 				}
 				err = os.Remove(path)
 				if err != nil {
-					red.Println("Erreur lors de la suppression de FreeTransCLI :", err, "PATH : ", path, "\nEssayer de refaire la commande en tant qu'administrateur/sudoeur ou de le supprimer vous même.")
-					os.Exit(0)
+					fail(withCode(exitIO, fmt.Errorf("suppression de %s impossible : %w\nEssayer de refaire la commande en tant qu'administrateur/sudoeur ou de le supprimer vous même", path, err)))
 				}
 			}
 			bgreen.Println("FreeTransCLI a été désinstallé avec succès. Merci d'avoir utiliser FreeTransCLI !")
@@ -1,13 +1,17 @@
 package cmd
 
 import (
-	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
+
+	"freetranscli/freetransfert"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/atotto/clipboard"
@@ -20,77 +24,143 @@ import (
 
 var (
 	filetype = "file"
-	url      string
-	i        int
-	size     int64
-
+	// Options de la commande upload
+	upPassword     bool
+	upPasswordFile string
+	upEncrypt      bool
+	upName         string
+	// Options d'archivage des dossiers et des fichiers multiples
+	upArchive        string
+	upCompression    string
+	upFollowSymlinks bool
+	url              string
+	i                int
 )
 
-func zipSource(source, target string) error {
-	// Compter la taille totale des fichiers à archiver
-	err := filepath.Walk(source, func(_ string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	if err != nil {
-		return err
+// Résultat de la commande upload avec --output json
+type uploadResult struct {
+	URL     string     `json:"url"`
+	Key     string     `json:"key"`
+	Files   []string   `json:"files"` //Chemins d'origine des fichiers téléversés
+	Size    int64      `json:"size"`
+	Expires *time.Time `json:"expires"`
+}
+
+// Taille maximale d'un transfert FreeTransfert
+const maxUploadSize = 50000000000
+
+// Lien de partage d'un transfert, avec la clé de déchiffrement dans le fragment s'il est chiffré
+func shareURLFor(key string, secret []byte) string {
+	if secret != nil {
+		return freetransfert.EncryptedShareURL(key, secret)
 	}
-	//Créer une progressbar qui affiche la taille totale des fichiers à archiver
-	bar := progressbar.DefaultBytes(size, cyan.Sprint("Archivage"))
-	// Créer un nouveau fichier zip
-	zipFile, err := os.Create(target)
+	return freetransfert.ShareURL(key)
+}
+
+// Créer un transfert sur FreeTransfert puis y envoyer le fichier sous le nom name (son nom si name est vide),
+// chiffré avec secret s'il n'est pas nil
+func sendFile(ctx context.Context, path, name string, opts freetransfert.TransferOptions, secret []byte, bar *progressbar.ProgressBar) (*freetransfert.CreatedTransfer, error) {
+	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer zipFile.Close()
+	defer file.Close()
 
-	// Créer un objet zip.Writer pour écrire
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	remote := freetransfert.File{Path: name, Size: info.Size()}
+	return sendReader(ctx, remote, file, info.Size(), opts, secret, bar)
+}
 
-	// Archiver les fichiers
-	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() {
-			// Ouvrir le fichier à archiver
-			fileToZip, err := os.Open(path)
-			if err != nil {
-				return err
+// Créer un transfert contenant le fichier remote puis y envoyer le contenu de r.
+// length vaut -1 si la taille du contenu n'est pas connue à l'avance, bar peut être nil.
+func sendReader(ctx context.Context, remote freetransfert.File, r io.Reader, length int64, opts freetransfert.TransferOptions, secret []byte, bar *progressbar.ProgressBar) (*freetransfert.CreatedTransfer, error) {
+	//Chiffrer le contenu au fil de l'envoi, le serveur ne reçoit jamais la clé
+	if secret != nil {
+		var err error
+		r, err = freetransfert.NewEncryptReader(r, secret)
+		if err != nil {
+			return nil, err
+		}
+		//Une taille inconnue n'est pas déclarée, chiffré ou non
+		if length >= 0 {
+			remote.Size = freetransfert.EncryptedSize(remote.Size)
+			length = freetransfert.EncryptedSize(length)
+			if bar != nil {
+				bar.ChangeMax64(length)
 			}
-			defer fileToZip.Close()
+		}
+	}
 
-			// Obtenir les informations sur le fichier à archiver
-			info, err := fileToZip.Stat()
-			if err != nil {
-				return err
-			}
-			//Mettre à jour la progressbar
-			bar.Add64(info.Size())
-			// Créer un header pour le fichier à archiver
-			header, err := zip.FileInfoHeader(info)
-			if err != nil {
-				return err
-			}
-			header.Name = strings.TrimPrefix(path, source+string(filepath.Separator))
+	// Déclarer le fichier auprès de l'API
+	transfer, err := client.CreateTransfer(ctx, []freetransfert.File{remote}, opts)
+	if err != nil {
+		return nil, err
+	}
 
-			// Ajouter le fichier à archiver au fichier zip
-			writer, err := zipWriter.CreateHeader(header)
-			if err != nil {
-				return err
-			}
-			_, err = io.Copy(writer, fileToZip)
-			if err != nil {
-				return err
-			}
+	// Envoyer le contenu du fichier, la progressbar avance au fur et à mesure de l'envoi
+	if bar != nil {
+		reader := progressbar.NewReader(r, bar)
+		r = &reader
+	}
+	if err := client.Upload(ctx, transfer.Files[0], r, length); err != nil {
+		return nil, err
+	}
 
-		}
-		return nil
-	})
+	return transfer, nil
+}
+
+// Lecteur comptant les octets lus
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Créer un transfert contenant un fichier nommé name lu depuis r (l'entrée standard) jusqu'à sa fin.
+// Renvoie aussi le nombre d'octets lus.
+func sendStream(ctx context.Context, r io.Reader, name string, opts freetransfert.TransferOptions, secret []byte) (*freetransfert.CreatedTransfer, int64, error) {
+	//La taille n'est pas connue d'avance : elle n'est pas déclarée, même chiffrée,
+	//et la progressbar indique seulement les octets envoyés
+	bar := bytesBar(-1, "Téléversement")
+	defer bar.Clear()
+	input := &countingReader{r: r}
+	transfer, err := sendReader(ctx, freetransfert.File{Path: name}, input, -1, opts, secret, bar)
+	return transfer, input.n, err
+}
+
+// Créer un transfert contenant une archive des fichiers et dossiers roots.
+// L'archive est écrite à la volée dans la requête d'envoi, sans fichier temporaire.
+func sendArchive(ctx context.Context, name string, roots []archiveRoot, archOpts archiveOptions, opts freetransfert.TransferOptions, secret []byte) (*freetransfert.CreatedTransfer, error) {
+	total, err := archiveSize(roots, archOpts.FollowSymlinks)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	//La progressbar suit la lecture des fichiers archivés
+	bar := bytesBar(total, "Téléversement")
+	defer bar.Clear()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeArchive(pw, roots, archOpts, bar))
+	}()
+
+	//La taille finale de l'archive n'est connue qu'à la fin de l'envoi, elle n'est pas déclarée
+	remote := freetransfert.File{Path: name}
+	transfer, err := sendReader(ctx, remote, pr, -1, opts, secret, nil)
+	//Arrêter l'archivage si l'envoi a échoué
+	pr.CloseWithError(errors.New("envoi interrompu"))
+	return transfer, err
 }
 
 // Transformer la taille en octets en une taille lisible
@@ -116,6 +186,28 @@ func readableSize(size int64) string {
 	}
 }
 
+// Afficher le QR code et copier l'adresse d'un transfert selon la configuration
+func shareLink(vp *viper.Viper, url string) {
+	//Vérifier si il faut afficher le qrcode
+	if vp.GetBool("cli.qrcode") {
+		//Imprimer le qrcode en petit
+		qrterminal.GenerateHalfBlock((url), qrterminal.L, os.Stdout)
+	}
+	//Vérifier si il faut copier l'adresse dans le presse-papier
+	if vp.GetBool("cli.clipboard") {
+		clipboard := clipboard.WriteAll(url)
+		if clipboard != nil {
+			yellow.Println("Scannez le QR code pour télécharger votre fichier, l'adresse n'a pas pu être copiée dans votre presse-papiers.", url)
+			return
+		}
+		green.Println("Scannez le QR code pour télécharger votre fichier, l'adresse est copiée dans votre presse-papiers.")
+	} else if vp.GetBool("cli.qrcode") {
+		green.Println("\rScannez le QR code pour télécharger votre fichier.", url)
+	} else {
+		green.Println("Votre fichier est disponible à l'adresse suivante :", url)
+	}
+}
+
 // uploadCmd represents the upload command
 var uploadCmd = &cobra.Command{
 	Use:   "upload",
@@ -123,6 +215,26 @@ var uploadCmd = &cobra.Command{
 	Long: `
 Téléverser un fichier sur FreeTransCLI grâce au chemin du fichier sur votre ordinateur.
 Exemple : freetranscli upload /Users/username/Documents/Hey.mov
+Avec - le fichier est lu depuis l'entrée standard : pg_dump base | freetranscli upload - --name dump.sql
+
+Options :
+  --name <nom>             Nom du fichier ou de l'archive sur FreeTransfert, nécessaire avec -
+  --password               Protéger le transfert par un mot de passe, demandé sans l'afficher
+                           ou lu dans la variable FREETRANSCLI_PASSWORD
+  --password-file <f>      Lire le mot de passe depuis un fichier
+  --expires <durée>        Durée de disponibilité du lien : 1d, 7d ou 30d
+  --message <texte>        Message joint au transfert
+  --to <a@b,c@d>           Envoyer le lien par e-mail à ces destinataires
+  --notify-on-download     Être prévenu à chaque téléchargement du transfert
+  --archive <format>       Format de l'archive des dossiers et fichiers multiples : zip (par défaut),
+                           tar, tar.gz ou tar.zst (conservent mieux les permissions et les liens)
+  --compression <niveau>   Compression de l'archive : store, fast ou best
+                           (dans un zip, les images, vidéos et archives sont stockées sans recompression)
+  --follow-symlinks        Archiver le contenu des liens symboliques au lieu des liens eux-mêmes
+  --encrypt                Chiffrer les fichiers avant l'envoi, la clé est ajoutée au lien après le #
+                           et n'est jamais envoyée au serveur
+
+Sans --expires, --message, --to ni --notify-on-download, ces options sont proposées avant l'envoi.
 
 Alias : up, u , upld`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -133,25 +245,73 @@ Alias : up, u , upld`,
 		vp.AddConfigPath(configDir)
 		err := vp.ReadInConfig()
 		if err != nil {
-			red.Println(err)
-			os.Exit(0)
+			fail(withCode(exitIO, err))
 		}
-		//Si aucun argument n'est donné en paramètre, on affiche une erreur
+		//Si aucun argument n'est donné en paramètre, on demande le chemin
 		if len(args) == 0 {
 			var input string
 			prompt := &survey.Input{
 				Message: "Chemin du fichier à téléverser :",
 			}
-			survey.AskOne(prompt, &input)
+			if err := ask(prompt, &input); err != nil {
+				fail(withCode(exitUsage, errors.New("aucun fichier à téléverser indiqué")))
+			}
 			args = append(args, input)
 			//Retirer les guillemets si il y en a
 			args[i] = strings.ReplaceAll(args[i], "'", "")
 		}
 
+		//Demander le mot de passe avant de préparer les fichiers
+		var opts freetransfert.TransferOptions
+		if upPassword || upPasswordFile != "" {
+			opts.Password, err = readPassword(upPasswordFile, true)
+			if err != nil {
+				fail(err)
+			}
+		}
+
+		//Durée de disponibilité, message et destinataires
+		if err := askTransferOptions(cmd, &opts); err != nil {
+			fail(withCode(exitUsage, err))
+		}
+
+		//Générer la clé de chiffrement, elle ne sera placée que dans le fragment du lien
+		var secret []byte
+		if upEncrypt {
+			secret, err = freetransfert.GenerateKey()
+			if err != nil {
+				fail(err)
+			}
+		}
+
+		var (
+			transfer *freetransfert.CreatedTransfer
+			sources  []string //Chemins d'origine des fichiers téléversés
+			uploaded int64    //Taille réellement envoyée
+		)
+		//Lire le fichier depuis l'entrée standard avec -
+		for _, arg := range args {
+			if strings.TrimSpace(arg) == "" {
+				fail(withCode(exitUsage, errors.New("le chemin du fichier à téléverser est vide")))
+			}
+			if arg == "-" && len(args) > 1 {
+				fail(withCode(exitUsage, errors.New("- ne peut pas être combiné à d'autres fichiers")))
+			}
+		}
+		fromStdin := args[0] == "-"
+		if fromStdin {
+			if upName == "" {
+				fail(withCode(exitUsage, errors.New("--name est nécessaire pour téléverser l'entrée standard")))
+			}
+			filetype = "file"
+			sources = []string{"-"}
+			transfer, uploaded, err = sendStream(cmd.Context(), os.Stdin, upName, opts, secret)
+			args = nil
+		}
 		//Vérifier qu'il n y a aucune erreur dans les fichiers
 		for i := len(args) - 1; i >= 0; i-- {
 			//Retirer le / a la fin du chemin si il y en a un
-			if args[i][len(args[i])-1:] == "/" {
+			if len(args[i]) > 1 && strings.HasSuffix(args[i], "/") {
 				args[i] = args[i][:len(args[i])-1]
 			}
 			_, err := os.Stat(args[i])
@@ -160,6 +320,10 @@ Alias : up, u , upld`,
 			if os.IsNotExist(err) {
 				filename := args[i]
 				if _, err := os.Stat(filename); os.IsNotExist(err) {
+					//Sans question possible, ne pas proposer de chemin similaire
+					if !interactive() {
+						fail(withCode(exitNotFound, fmt.Errorf("le fichier %s n'existe pas", filename)))
+					}
 					red.Printf("Erreur : Le fichier %s n'existe pas, vérifiez que vous avez bien écrit le chemin du fichier.\n", filename)
 
 					if vp.GetBool("cli.notfound") {
@@ -167,15 +331,14 @@ Alias : up, u , upld`,
 						matches, err := filepath.Glob(filepath.Join(dir, "*"+file+"*"))
 
 						if err != nil {
-							red.Printf("Erreur : Impossible de rechercher des fichiers similaires pour %s : %s\n", filename, err)
-							return
+							fail(fmt.Errorf("impossible de rechercher des fichiers similaires pour %s : %w", filename, err))
 						}
 
 						if len(matches) == 0 {
 							red.Printf("Aucun fichier similaire trouvé pour %s\n", filename)
 
 							if len(args) == 1 {
-								return
+								os.Exit(exitNotFound)
 							}
 							continue
 						}
@@ -187,11 +350,10 @@ Alias : up, u , upld`,
 							Message: fmt.Sprintf("Voulez-vous utiliser le chemin similaire %s ?", matches[0]),
 							Default: true,
 						}
-						err = survey.AskOne(prompt, &confirm)
+						err = ask(prompt, &confirm)
 
 						if err != nil {
-							red.Printf("Erreur : Impossible de lire la réponse de l'utilisateur : %s\n", err)
-							return
+							fail(fmt.Errorf("impossible de lire la réponse de l'utilisateur : %w", err))
 						}
 						if confirm {
 							args[i] = matches[0]
@@ -200,118 +362,112 @@ Alias : up, u , upld`,
 						}
 					} else {
 						if len(args) == 1 {
-							return
+							os.Exit(exitNotFound)
 						}
 						continue
 					}
 
 				}
-				continue
+				//Le chemin similaire accepté remplace le chemin donné
+				if _, err = os.Stat(args[i]); err != nil {
+					continue
+				}
 			}
 
 			//Si le fichier n'a pas les droits d'accès, on affiche une erreur
 			if os.IsPermission(err) {
-				red.Println("Erreur : Permission refusée, vérifiez que vous avez les droits d'accès au fichier, avez-vous lancé le programme en tant qu'administrateur/sudoeur ?")
+				permErr := withCode(exitIO, errors.New("permission refusée, vérifiez que vous avez les droits d'accès au fichier, avez-vous lancé le programme en tant qu'administrateur/sudoeur ?"))
+				if !interactive() {
+					fail(permErr)
+				}
+				red.Printf("Erreur : %s\n", permErr)
 				continue
 			}
 
-			file, _ := os.Stat(args[i])
-			size := file.Size()
-
+			file, err := os.Stat(args[i])
+			if err != nil {
+				fail(statError(args[i], err))
+			}
 			//si le fichier est plus gros que 50go, on affiche une erreur
-			if size > 50000000000 {
-				red.Println("Erreur : Vous ne pouvez pas upload un fichier plus gros que 50Go.")
-				os.Exit(0)
+			if file.Size() > maxUploadSize {
+				fail(withCode(exitUsage, errors.New("vous ne pouvez pas upload un fichier plus gros que 50Go")))
 			}
+			absPath, _ := filepath.Abs(args[i])
+			sources = append([]string{absPath}, sources...)
+		} //Fin de la boucle for
+		if len(sources) == 0 {
+			os.Exit(exitNotFound)
+		}
 
-			//si dans args il y a plus d'un fichier on attend de récupérer tous les fichiers pour les mettres dans un dossier
-			if len(args) > 1 {
-				// Si le dossier temporaire n'existe pas alors on le créé
-				if _, err := os.Stat(tempDir + "/free-transfert"); os.IsNotExist(err) {
-					err := os.Mkdir(tempDir+"/free-transfert", 0755)
-					if err != nil {
-						red.Println(err)
-						os.Exit(0)
-					}
-				}
-				//Executer la commande cp
-				err := exec.Command("cp", "-R" , args[i], tempDir+"/free-transfert").Run()
-
-				if err != nil {
-					red.Println(err)
-					os.Exit(0)
-				}
-				if i > 0 {
-					//Revenir au début de la boucle
-					continue
-				}
-				if i == 0 {
-					args[i] = tempDir + "/free-transfert" + ".zip"
-
-					//Archiver le dossier
-					if err := zipSource(tempDir+"/free-transfert", args[i]); err != nil {
-						red.Println(err)
-						os.Exit(0)
-					}
-					// Supprimer le dossier temporaire
-					err := os.RemoveAll(tempDir + "/free-transfert")
-					if err != nil {
-						red.Println(err)
-						os.Exit(0)
-					}
-				}
-				file, _ := os.Stat(args[i])
-				size = file.Size()
+		if fromStdin {
+			//L'entrée standard a déjà été envoyée
+		} else if len(sources) == 1 {
+			//Un seul fichier est envoyé tel quel
+			file, statErr := os.Stat(sources[0])
+			if statErr != nil {
+				fail(statError(sources[0], statErr))
 			}
-			//Si c'est un dossier
-			if file.IsDir() && len(args) == 1 {
-				//si le dossier est plus gros que 50go
-				if size > 50000000000 {
-					red.Println("Erreur : Vous ne pouvez pas upload un dossier plus gros que 50Go.")
-					os.Exit(0)
-				}
-				//Archiver le dossier
-				if err := zipSource(args[0], args[i]+".zip"); err != nil {
-					red.Println(err, "Désolé essayez de le compresser vous même…")
-					//Supprimer définitivement le fichier
-					os.Remove(args[i] + ".zip")
-					os.Exit(0)
-				} else if err == nil {
-					//Supprimer définitivement le fichier
-					args[i] = args[i] + ".zip"
-					file, _ := os.Stat(args[i])
-					size = file.Size()
-					// os.Remove(args[i] + ".zip")
-				}
+			if !file.IsDir() {
+				filetype = "file"
+				uploaded = file.Size()
+				//Progress bar pour le téléversement
+				bar := bytesBar(uploaded, "Téléversement")
+				//Envoyer le fichier sur FreeTransfert
+				transfer, err = sendFile(cmd.Context(), sources[0], upName, opts, secret, bar)
+				//Supprimer la progressbar
+				bar.Clear()
+			} else {
+				filetype = "folder"
 			}
-			//Progress bar pour le téléversement
-			bar := progressbar.DefaultBytes(
-				size,
-				green.Sprint("Téléversement"),
-			)
-			//Ajouter un fichier au reader
-			reader, err := os.Open(args[i])
-			if err != nil {
-				red.Println(err)
-				os.Exit(0)
+		} else {
+			filetype = "files"
+		}
+		//Les dossiers et les fichiers multiples sont archivés dans un fichier temporaire avant l'envoi
+		if !fromStdin && filetype != "file" {
+			archOpts, optsErr := parseArchiveOptions(upArchive, upCompression)
+			if optsErr != nil {
+				fail(withCode(exitUsage, optsErr))
 			}
-
-			//Faire avancer la progressbar
-			_, err = io.Copy(bar, reader)
-			if err != nil {
-				red.Println(err)
-				os.Exit(0)
+			archOpts.FollowSymlinks = upFollowSymlinks
+			name := "free-transfert" + archOpts.Ext()
+			if upName != "" {
+				name = upName
+			} else if filetype == "folder" {
+				name = filepath.Base(sources[0]) + archOpts.Ext()
 			}
-			//Supprimer la progressbar
-			// fmt.Print("\033[2K\r")
-
-		} //Fin de la boucle for
-		url = "https://github.com/mdp/qrterminal"
+			roots := archiveRoots(sources)
+			if uploaded, err = archiveSize(roots, archOpts.FollowSymlinks); err == nil && uploaded > maxUploadSize {
+				fail(withCode(exitUsage, errors.New("vous ne pouvez pas upload plus de 50Go")))
+			}
+			transfer, err = sendArchive(cmd.Context(), name, roots, archOpts, opts, secret)
+		}
+		if err != nil {
+			fail(fmt.Errorf("téléversement impossible : %w", err))
+		}
+		url = shareURLFor(transfer.Key, secret)
+		//Si l'API n'a pas donné la date d'expiration à la création, la demander
+		if transfer.ExpiresAt == nil {
+			if info, err := client.GetTransfer(cmd.Context(), transfer.Key); err == nil {
+				transfer.ExpiresAt = info.ExpiresAt
+			}
+		}
 
 		//Enregistre les données dans un fichier d'historique si l'historique est activé
 		if vp.GetBool("cli.history") {
-			absPath, _ := filepath.Abs(args[i])                  //Chemin des fichiers
-			historic(url, absPath, filetype, readableSize(size)) //Enregistrer dans l'historique avec l'url, le chemin du fichier, le type de fichier et la taille du fichier
+			historic(historyEntry{
+				Direction:   directionUpload,
+				TransferKey: transfer.Key,
+				URL:         url,
+				Paths:       sources,
+				Type:        filetype,
+				Size:        uploaded,
+				ExpiresAt:   transfer.ExpiresAt,
+				DeleteKey:   transfer.DeleteKey,
+				Message:     opts.Message,
+				Recipients:  opts.Recipients,
+				Notify:      opts.NotifyOnDownload,
+				Protected:   opts.Password != "",
+			})
 		}
 
 		//Vérifier si il faut afficher une notification et si il le faut avec du son.
@@ -321,30 +477,45 @@ Alias : up, u , upld`,
 			beeep.Notify("FreeTransCLI", "Votre fichier a bien été upload.", vp.GetString("cli.icon"))
 		}
 
-		//Vérifier si il faut afficher le qrcode
-		if vp.GetBool("cli.qrcode") {
-			//Imprimer le qrcode en petit
-			qrterminal.GenerateHalfBlock((url), qrterminal.L, os.Stdout)
-		}
-		//Vérifier si il faut copier l'adresse dans le presse-papier
-		if vp.GetBool("cli.clipboard") {
-			clipboard := clipboard.WriteAll(url)
-			if clipboard != nil {
-				yellow.Println("Scannez le QR code pour télécharger votre fichier, l'adresse n'a pas pu être copiée dans votre presse-papiers.", url)
-				os.Exit(0)
+		if jsonOutput() {
+			err := printJSON(uploadResult{
+				URL:     url,
+				Key:     transfer.Key,
+				Files:   sources,
+				Size:    uploaded,
+				Expires: transfer.ExpiresAt,
+			})
+			if err != nil {
+				fail(withCode(exitIO, err))
 			}
-			green.Println("Scannez le QR code pour télécharger votre fichier, l'adresse est copiée dans votre presse-papiers.")
-		} else if vp.GetBool("cli.qrcode") {
-			green.Println("\rScannez le QR code pour télécharger votre fichier.", url)
-		} else {
-			green.Println("Votre fichier est disponible à l'adresse suivante :", url)
+			return
 		}
-
+		shareLink(vp, url)
 	},
 }
 
+// Classer une erreur de lecture d'un fichier à téléverser : un chemin qui traverse un fichier
+// (ENOTDIR) est introuvable, les autres erreurs sont des erreurs de lecture
+func statError(path string, err error) error {
+	if os.IsNotExist(err) || errors.Is(err, syscall.ENOTDIR) {
+		return withCode(exitNotFound, fmt.Errorf("le fichier %s n'existe pas", path))
+	}
+	return withCode(exitIO, fmt.Errorf("impossible de lire %s : %w", path, err))
+}
+
 func init() {
 	rootCmd.AddCommand(uploadCmd)
-	uploadCmd.SetUsageTemplate("Usage: freetranscli upload [file]\n\n")
+	uploadCmd.SetUsageTemplate("Usage: freetranscli upload [file|-] [--expires 1d|7d|30d] [--message texte] [--to a@b,c@d] [--notify-on-download] [--name nom] [--password] [--password-file fichier] [--archive zip|tar|tar.gz|tar.zst] [--compression store|fast|best] [--follow-symlinks] [--encrypt]\n\n")
 	uploadCmd.Aliases = []string{"up", "u", "upld"}
+	uploadCmd.Flags().StringVar(&upExpires, "expires", "", "Durée de disponibilité du lien : 1d, 7d ou 30d")
+	uploadCmd.Flags().StringVar(&upMessage, "message", "", "Message joint au transfert")
+	uploadCmd.Flags().StringVar(&upTo, "to", "", "Destinataires du lien, séparés par des virgules")
+	uploadCmd.Flags().BoolVar(&upNotify, "notify-on-download", false, "Être prévenu à chaque téléchargement du transfert")
+	uploadCmd.Flags().StringVar(&upName, "name", "", "Nom du fichier sur FreeTransfert, nécessaire avec -")
+	uploadCmd.Flags().BoolVar(&upPassword, "password", false, "Protéger le transfert par un mot de passe")
+	uploadCmd.Flags().StringVar(&upPasswordFile, "password-file", "", "Lire le mot de passe depuis un fichier")
+	uploadCmd.Flags().StringVar(&upArchive, "archive", "zip", "Format de l'archive : zip, tar, tar.gz ou tar.zst")
+	uploadCmd.Flags().StringVar(&upCompression, "compression", "", "Compression de l'archive : store, fast ou best")
+	uploadCmd.Flags().BoolVar(&upFollowSymlinks, "follow-symlinks", false, "Archiver le contenu des liens symboliques")
+	uploadCmd.Flags().BoolVar(&upEncrypt, "encrypt", false, "Chiffrer les fichiers de bout en bout")
 }
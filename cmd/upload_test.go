@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"freetranscli/freetransfert"
+)
+
+// La taille d'une archive créée à la volée n'est pas connue d'avance et ne doit pas être déclarée,
+// y compris lorsqu'elle est chiffrée
+func TestSendArchiveDeclaresNoSize(t *testing.T) {
+	api := newFakeAPI(t)
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), bytes.Repeat([]byte("a"), 3000), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), bytes.Repeat([]byte("b"), 3000), 0644)
+
+	for _, encrypt := range []bool{false, true} {
+		var secret []byte
+		if encrypt {
+			secret, _ = freetransfert.GenerateKey()
+		}
+		roots := archiveRoots([]string{dir})
+		transfer, err := sendArchive(context.Background(), "dossier.zip", roots, archiveOptions{Format: formatZip}, freetransfert.TransferOptions{}, secret)
+		if err != nil {
+			t.Fatalf("chiffré %v : %v", encrypt, err)
+		}
+		sent := api.transfer(transfer.Key)
+		if sent.Files[0].Size != 0 {
+			t.Fatalf("chiffré %v : taille déclarée %d", encrypt, sent.Files[0].Size)
+		}
+		if !encrypt {
+			data := sent.Content["dossier.zip"]
+			if _, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+				t.Fatalf("archive reçue invalide : %v", err)
+			}
+		}
+	}
+}
+
+// Un flux de taille inconnue est envoyé sans taille déclarée, chiffré ou non, et se télécharge entièrement
+func TestSendStreamRoundTrip(t *testing.T) {
+	newFakeAPI(t)
+	content := []byte("SELECT 1;\n")
+
+	for _, encrypt := range []bool{false, true} {
+		var secret []byte
+		if encrypt {
+			secret, _ = freetransfert.GenerateKey()
+		}
+		transfer, n, err := sendStream(context.Background(), bytes.NewReader(content), "dump.sql", freetransfert.TransferOptions{}, secret)
+		if err != nil {
+			t.Fatalf("chiffré %v : %v", encrypt, err)
+		}
+		if n != int64(len(content)) {
+			t.Fatalf("chiffré %v : %d octets lus au lieu de %d", encrypt, n, len(content))
+		}
+
+		dir := t.TempDir()
+		result := downloadTransfer(context.Background(), shareURLFor(transfer.Key, secret), testDownloadConfig(dir))
+		if result.Err != nil {
+			t.Fatalf("chiffré %v : téléchargement : %v", encrypt, result.Err)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "dump.sql"))
+		if err != nil || !bytes.Equal(got, content) {
+			t.Fatalf("chiffré %v : contenu téléchargé %q (%v)", encrypt, got, err)
+		}
+	}
+}
+
+// sendFile déclare le fichier avec sa taille, envoie son contenu et renvoie la clé du lien de partage
+func TestSendFile(t *testing.T) {
+	api := newFakeAPI(t)
+	path := filepath.Join(t.TempDir(), "rapport.pdf")
+	content := bytes.Repeat([]byte("%PDF"), 1000)
+	os.WriteFile(path, content, 0644)
+
+	transfer, err := sendFile(context.Background(), path, "", freetransfert.TransferOptions{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := api.transfer(transfer.Key)
+	if sent == nil {
+		t.Fatalf("transfert %s inconnu du serveur", transfer.Key)
+	}
+	if len(sent.Files) != 1 || sent.Files[0].Path != "rapport.pdf" || sent.Files[0].Size != int64(len(content)) {
+		t.Fatalf("fichier déclaré inattendu : %+v", sent.Files)
+	}
+	if !bytes.Equal(sent.Content["rapport.pdf"], content) {
+		t.Fatalf("contenu reçu incorrect : %d octets", len(sent.Content["rapport.pdf"]))
+	}
+	if got, want := shareURLFor(transfer.Key, nil), "https://transfert.free.fr/"+transfer.Key; got != want {
+		t.Fatalf("lien de partage %s, attendu %s", got, want)
+	}
+	if transfer.DeleteKey == "" {
+		t.Fatal("jeton de suppression manquant")
+	}
+}
+
+// Un fichier chiffré est déclaré avec sa taille chiffrée et le serveur ne reçoit pas le contenu en clair
+func TestSendFileEncrypted(t *testing.T) {
+	api := newFakeAPI(t)
+	path := filepath.Join(t.TempDir(), "secret.txt")
+	content := []byte("contenu confidentiel")
+	os.WriteFile(path, content, 0644)
+	secret, _ := freetransfert.GenerateKey()
+
+	transfer, err := sendFile(context.Background(), path, "renommé.txt", freetransfert.TransferOptions{}, secret, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := api.transfer(transfer.Key)
+	data := sent.Content["renommé.txt"]
+	if sent.Files[0].Size != freetransfert.EncryptedSize(int64(len(content))) || int64(len(data)) != sent.Files[0].Size {
+		t.Fatalf("taille déclarée %d, reçue %d", sent.Files[0].Size, len(data))
+	}
+	if bytes.Contains(data, content) {
+		t.Fatal("le serveur a reçu le contenu en clair")
+	}
+}
+
+// Un chemin qui traverse un fichier est introuvable, et non une erreur inattendue
+func TestStatError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(file, []byte("a"), 0644)
+	for _, path := range []string{filepath.Join(file, "x"), filepath.Join(file, "..", "absent")} {
+		_, err := os.Stat(path)
+		if err == nil {
+			t.Fatalf("%s existe", path)
+		}
+		if code := exitCode(statError(path, err)); code != exitNotFound {
+			t.Errorf("%s : code %d, attendu %d", path, code, exitNotFound)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package freetransfert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultBaseURL est l'adresse de l'API utilisée par transfert.free.fr
+const DefaultBaseURL = "https://api.scw.iliad.fr/freetransfert/v2"
+
+// Client permet d'interroger l'API de FreeTransfert.
+// BaseURL et HTTPClient peuvent être modifiés, par exemple pour viser un serveur de test.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Dossier des copies temporaires des contenus de taille inconnue, le dossier temporaire du système si vide
+	TempDir string
+}
+
+// NewClient renvoie un client pointant vers l'API officielle
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// APIError est renvoyée lorsque l'API répond avec un statut d'erreur
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Code != "" {
+		return e.Code
+	}
+	return fmt.Sprintf("l'API a répondu %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// IsUnauthorized indique si err signale un mot de passe manquant ou incorrect
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
+
+// IsNotFound indique si err signale un transfert ou un fichier inexistant ou expiré
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusGone
+}
+
+// Champs d'erreur présents dans les réponses de l'API
+type errorBody struct {
+	Error   interface{} `json:"error"`
+	Message interface{} `json:"message"`
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return c.HTTPClient
+}
+
+// Envoyer une requête JSON à l'API et décoder la réponse dans out
+func (c *Client) do(ctx context.Context, method, path string, header http.Header, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	//Vérifier si l'API a renvoyé une erreur, une réponse réussie peut contenir un message (celui du transfert)
+	if resp.StatusCode >= 400 {
+		var apiErr errorBody
+		if len(data) > 0 && json.Unmarshal(data, &apiErr) == nil && (apiErr.Error != nil || apiErr.Message != nil) {
+			return newAPIError(resp.StatusCode, apiErr)
+		}
+	}
+	if resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode}
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func newAPIError(status int, body errorBody) *APIError {
+	e := &APIError{StatusCode: status}
+	if body.Error != nil {
+		e.Code = fmt.Sprint(body.Error)
+	}
+	if body.Message != nil {
+		e.Message = fmt.Sprint(body.Message)
+	}
+	return e
+}
@@ -0,0 +1,56 @@
+package freetransfert
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Serveur de test répondant toujours status et body
+func newTestAPI(t *testing.T, status int, body string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{BaseURL: srv.URL, HTTPClient: srv.Client()}
+}
+
+func TestGetTransferWithMessage(t *testing.T) {
+	client := newTestAPI(t, http.StatusOK, `{"transferKey":"abcd","files":[{"path":"a.txt","size":3}],"message":"Bonjour, voici les fichiers"}`)
+	transfer, err := client.GetTransfer(context.Background(), "abcd")
+	if err != nil {
+		t.Fatalf("GetTransfer : %v", err)
+	}
+	if transfer.Key != "abcd" || len(transfer.Files) != 1 || transfer.Files[0].Path != "a.txt" {
+		t.Fatalf("transfert inattendu : %+v", transfer)
+	}
+}
+
+func TestAPIErrorBody(t *testing.T) {
+	client := newTestAPI(t, http.StatusNotFound, `{"error":"not_found","message":"Transfert introuvable"}`)
+	_, err := client.GetTransfer(context.Background(), "abcd")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("erreur attendue de type *APIError, obtenu %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "not_found" || apiErr.Message != "Transfert introuvable" {
+		t.Fatalf("erreur inattendue : %+v", apiErr)
+	}
+	if !IsNotFound(err) {
+		t.Fatal("IsNotFound devrait être vrai pour un 404")
+	}
+}
+
+func TestAPIErrorWithoutBody(t *testing.T) {
+	client := newTestAPI(t, http.StatusInternalServerError, "")
+	_, err := client.GetTransfer(context.Background(), "abcd")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("erreur 500 attendue, obtenu %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+// Package freetransfert implémente un client pour l'API de FreeTransfert.
+//
+// FreeTransfert ne publie pas de documentation de son API. Seules deux routes sont connues
+// par l'usage, celles que la commande download utilisait déjà avant ce client :
+//
+//	GET /transfers/{key}                  fichiers du transfert (files, zip) ou {error, message}
+//	GET /files?transferKey={key}&path=…   adresse signée de téléchargement ({url})
+//
+// Le reste du contrat est supposé et n'a pas encore été vérifié auprès du service :
+//
+//	POST /transfers            corps {files: [{path, size}], availability, message, recipients,
+//	                           notifyOnDownload, password}, réponse {transferKey, files: [{path,
+//	                           uploadUrl}], deleteKey, expiresAt}
+//	PUT {uploadUrl}            contenu brut du fichier, avec Content-Length
+//	DELETE /transfers/{key}    corps {deleteKey}
+//	X-Transfer-Password        en-tête portant le mot de passe d'un transfert protégé
+//	passwordProtected          champ de GET /transfers/{key} pour un transfert protégé
+//
+// Les tests de la commande (cmd/fakeapi_test.go) reposent sur un faux serveur qui implémente
+// ce contrat supposé : ils vérifient le comportement de FreeTransCLI, pas celui du service.
+// Toute différence constatée avec le service réel doit être corrigée ici et dans ce faux serveur.
+package freetransfert
@@ -0,0 +1,201 @@
+package freetransfert
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Chiffrement de bout en bout des fichiers téléversés.
+//
+// Le contenu est découpé en morceaux de ChunkSize octets chiffrés avec XChaCha20-Poly1305.
+// Le nonce de chaque morceau est formé d'un préfixe aléatoire, d'un compteur et d'un octet
+// indiquant le dernier morceau, ce qui empêche de réordonner ou de tronquer le contenu.
+// Le dernier morceau est toujours plus court que ChunkSize, éventuellement vide.
+//
+//	en-tête : "FTC1" | préfixe (15 octets)
+//	morceau : texte chiffré | étiquette (16 octets)
+//
+// La clé n'est jamais envoyée au serveur : elle est placée dans le fragment du lien de partage.
+
+// ChunkSize est la taille en clair d'un morceau chiffré
+const ChunkSize = 64 * 1024
+
+// KeySize est la taille d'une clé de chiffrement
+const KeySize = chacha20poly1305.KeySize
+
+var magic = []byte("FTC1")
+
+const (
+	prefixSize = chacha20poly1305.NonceSizeX - 8 - 1
+	headerSize = 4 + prefixSize
+	tagSize    = chacha20poly1305.Overhead
+)
+
+// ErrCorrupted est renvoyée lorsqu'un morceau chiffré a été modifié ou que le contenu est incomplet
+var ErrCorrupted = errors.New("contenu chiffré invalide ou modifié")
+
+// GenerateKey crée une nouvelle clé de chiffrement aléatoire
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncryptedSize renvoie la taille chiffrée d'un contenu de size octets
+func EncryptedSize(size int64) int64 {
+	chunks := size/ChunkSize + 1
+	return headerSize + size + chunks*tagSize
+}
+
+// EncryptedShareURL renvoie le lien de partage d'un transfert chiffré, la clé étant placée dans le fragment
+func EncryptedShareURL(transferKey string, key []byte) string {
+	return ShareURL(transferKey) + "#" + base64.RawURLEncoding.EncodeToString(key)
+}
+
+// ParseEncryptionKey extrait la clé de chiffrement du fragment d'un lien de partage,
+// nil si le lien n'en contient pas
+func ParseEncryptionKey(link string) ([]byte, error) {
+	link = strings.Trim(strings.TrimSpace(link), `'"`)
+	i := strings.Index(link, "#")
+	if i < 0 || i == len(link)-1 {
+		return nil, nil
+	}
+	fragment, err := url.PathUnescape(link[i+1:])
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(fragment, "="))
+	if err != nil || len(key) != KeySize {
+		return nil, fmt.Errorf("clé de chiffrement invalide dans le lien")
+	}
+	return key, nil
+}
+
+// Nonce d'un morceau : préfixe | compteur | dernier morceau
+func chunkNonce(prefix []byte, counter uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[prefixSize:], counter)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// Lecteur produisant le contenu chiffré d'un autre lecteur
+type encryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	plain   []byte
+	out     []byte // Contenu chiffré pas encore lu
+	done    bool
+}
+
+// NewEncryptReader renvoie un lecteur produisant le contenu de r chiffré avec key
+func NewEncryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, prefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, err
+	}
+	header := append(append([]byte{}, magic...), prefix...)
+	return &encryptReader{
+		src:    r,
+		aead:   aead,
+		prefix: prefix,
+		plain:  make([]byte, ChunkSize),
+		out:    header,
+	}, nil
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(e.src, e.plain)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		//Un morceau incomplet est le dernier
+		last := n < ChunkSize
+		e.out = e.aead.Seal(e.out[:0], chunkNonce(e.prefix, e.counter, last), e.plain[:n], nil)
+		e.counter++
+		e.done = last
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+// Lecteur produisant le contenu déchiffré d'un autre lecteur, en vérifiant chaque morceau
+type decryptReader struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	sealed  []byte
+	out     []byte // Contenu déchiffré pas encore lu
+	done    bool
+}
+
+// NewDecryptReader renvoie un lecteur produisant le contenu déchiffré de r.
+// Une erreur ErrCorrupted est renvoyée dès qu'un morceau ne peut pas être authentifié.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrCorrupted
+	}
+	if string(header[:len(magic)]) != string(magic) {
+		return nil, fmt.Errorf("ce fichier n'a pas été chiffré par FreeTransCLI")
+	}
+	return &decryptReader{
+		src:    r,
+		aead:   aead,
+		prefix: header[len(magic):],
+		sealed: make([]byte, ChunkSize+tagSize),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.src, d.sealed)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return 0, err
+		}
+		last := n < len(d.sealed)
+		plain, err := d.aead.Open(d.sealed[:0], chunkNonce(d.prefix, d.counter, last), d.sealed[:n], nil)
+		if err != nil {
+			return 0, ErrCorrupted
+		}
+		d.out = plain
+		d.counter++
+		d.done = last
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
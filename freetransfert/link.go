@@ -0,0 +1,75 @@
+package freetransfert
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ShareBaseURL est l'adresse publique des transferts, suivie de leur clé
+const ShareBaseURL = "https://transfert.free.fr/"
+
+// Noms de domaine sur lesquels un lien de transfert peut pointer
+var knownHosts = map[string]bool{
+	"transfert.free.fr":     true,
+	"www.transfert.free.fr": true,
+	"freetransfert.free.fr": true,
+	apiHost:                 true,
+}
+
+// Nom de domaine de l'API, où la clé suit /transfers/ dans le chemin
+const apiHost = "api.scw.iliad.fr"
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{4,64}$`)
+
+// ParseKey extrait la clé d'un transfert à partir d'un lien de partage ou d'une clé seule.
+// Les liens avec ou sans schéma, avec une barre oblique finale, une requête ou un fragment sont acceptés.
+func ParseKey(link string) (string, error) {
+	link = strings.TrimSpace(link)
+	link = strings.Trim(link, `'"`)
+	if link == "" {
+		return "", fmt.Errorf("aucun lien FreeTransfert fourni")
+	}
+	if keyPattern.MatchString(link) {
+		return link, nil
+	}
+
+	raw := link
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("lien FreeTransfert invalide : %s", link)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("lien FreeTransfert invalide : %s", link)
+	}
+	if !knownHosts[strings.ToLower(u.Hostname())] {
+		return "", fmt.Errorf("%s n'est pas une adresse FreeTransfert", u.Hostname())
+	}
+
+	//Sur les adresses de partage, la clé est le premier élément du chemin (…/clé/download),
+	//sur l'API, celui qui suit transfers (…/v2/transfers/clé/files)
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	key := segments[0]
+	if strings.ToLower(u.Hostname()) == apiHost {
+		key = ""
+		for n := 0; n < len(segments)-1; n++ {
+			if segments[n] == "transfers" {
+				key = segments[n+1]
+				break
+			}
+		}
+	}
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("aucune clé de transfert trouvée dans %s", link)
+	}
+	return key, nil
+}
+
+// ShareURL renvoie le lien de partage d'un transfert
+func ShareURL(key string) string {
+	return ShareBaseURL + key
+}
@@ -0,0 +1,49 @@
+package freetransfert
+
+import "testing"
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		key  string // "" si une erreur est attendue
+	}{
+		{"clé seule", "2kxQZv", "2kxQZv"},
+		{"clé entre guillemets", "'2kxQZv'", "2kxQZv"},
+		{"lien", "https://transfert.free.fr/2kxQZv", "2kxQZv"},
+		{"sans schéma", "transfert.free.fr/2kxQZv", "2kxQZv"},
+		{"http", "http://transfert.free.fr/2kxQZv", "2kxQZv"},
+		{"barre finale", "https://transfert.free.fr/2kxQZv/", "2kxQZv"},
+		{"requête", "https://transfert.free.fr/2kxQZv?lang=fr", "2kxQZv"},
+		{"fragment", "https://transfert.free.fr/2kxQZv#clé-de-chiffrement", "2kxQZv"},
+		{"élément supplémentaire", "https://transfert.free.fr/2kxQZv/download", "2kxQZv"},
+		{"éléments supplémentaires", "https://transfert.free.fr/2kxQZv/files/a.txt", "2kxQZv"},
+		{"sous-domaine www", "https://www.transfert.free.fr/2kxQZv", "2kxQZv"},
+		{"majuscules dans l'hôte", "https://Transfert.Free.fr/2kxQZv", "2kxQZv"},
+		{"espaces", "  https://transfert.free.fr/2kxQZv \n", "2kxQZv"},
+		{"API", "https://api.scw.iliad.fr/freetransfert/v2/transfers/2kxQZv", "2kxQZv"},
+		{"API avec élément supplémentaire", "https://api.scw.iliad.fr/freetransfert/v2/transfers/2kxQZv/files", "2kxQZv"},
+		{"API sans transfers", "https://api.scw.iliad.fr/freetransfert/v2/2kxQZv", ""},
+		{"hôte inconnu", "https://example.com/2kxQZv", ""},
+		{"hôte ressemblant", "https://transfert.free.fr.example.com/2kxQZv", ""},
+		{"schéma invalide", "ftp://transfert.free.fr/2kxQZv", ""},
+		{"sans clé", "https://transfert.free.fr/", ""},
+		{"clé invalide", "https://transfert.free.fr/a", ""},
+		{"vide", "", ""},
+		{"espaces seuls", "   ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.link)
+			if tt.key == "" {
+				if err == nil {
+					t.Fatalf("ParseKey(%q) = %q, une erreur était attendue", tt.link, key)
+				}
+				return
+			}
+			if err != nil || key != tt.key {
+				t.Fatalf("ParseKey(%q) = %q, %v ; attendu %q", tt.link, key, err, tt.key)
+			}
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package freetransfert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// File décrit un fichier d'un transfert
+type File struct {
+	Path string `json:"path"`
+	// Taille déclarée à la création du transfert, 0 si elle n'était pas connue.
+	// Seule la taille annoncée par le serveur au téléchargement fait foi.
+	Size int64 `json:"size,omitempty"`
+}
+
+// En-tête portant le mot de passe d'un transfert protégé
+const passwordHeader = "X-Transfer-Password"
+
+// Transfer décrit un transfert tel que renvoyé par /transfers/{key}
+type Transfer struct {
+	Key   string `json:"transferKey"`
+	Files []File `json:"files"`
+	// Archive contenant tous les fichiers, absente si le transfert n'en a pas
+	Zip *File `json:"zip"`
+	// Vrai si un mot de passe est demandé pour télécharger les fichiers
+	PasswordProtected bool `json:"passwordProtected"`
+	// Date d'expiration et nombre de téléchargements, absents si l'API ne les fournit pas
+	ExpiresAt     *time.Time `json:"expiresAt"`
+	DownloadCount *int       `json:"downloadCount"`
+}
+
+// TotalSize renvoie la taille cumulée des fichiers du transfert
+func (t *Transfer) TotalSize() int64 {
+	var total int64
+	for _, file := range t.Files {
+		total += file.Size
+	}
+	return total
+}
+
+// SignedURL est une adresse temporaire permettant de télécharger un fichier
+type SignedURL struct {
+	URL string `json:"url"`
+}
+
+// UploadTarget indique où envoyer le contenu d'un fichier déclaré à la création d'un transfert
+type UploadTarget struct {
+	Path      string `json:"path"`
+	UploadURL string `json:"uploadUrl"`
+}
+
+// CreatedTransfer est la réponse de l'API à la création d'un transfert
+type CreatedTransfer struct {
+	Key   string         `json:"transferKey"`
+	Files []UploadTarget `json:"files"`
+	// Jeton permettant de supprimer le transfert, à conserver par celui qui l'a créé
+	DeleteKey string     `json:"deleteKey"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// GetTransfer renvoie les informations d'un transfert
+func (c *Client) GetTransfer(ctx context.Context, key string) (*Transfer, error) {
+	var transfer Transfer
+	if err := c.do(ctx, http.MethodGet, "/transfers/"+url.PathEscape(key), nil, nil, &transfer); err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// FileURL renvoie l'adresse de téléchargement d'un fichier d'un transfert.
+// password est ignoré si le transfert n'est pas protégé.
+func (c *Client) FileURL(ctx context.Context, key, path, password string) (*SignedURL, error) {
+	query := url.Values{}
+	query.Set("transferKey", key)
+	query.Set("path", path)
+
+	//Le mot de passe est envoyé dans un en-tête pour ne pas apparaître dans les journaux des serveurs
+	header := http.Header{}
+	if password != "" {
+		header.Set(passwordHeader, password)
+	}
+
+	var signed SignedURL
+	if err := c.do(ctx, http.MethodGet, "/files?"+query.Encode(), header, nil, &signed); err != nil {
+		return nil, err
+	}
+	if signed.URL == "" {
+		return nil, errors.New("aucune adresse de téléchargement renvoyée par l'API")
+	}
+	return &signed, nil
+}
+
+// Durées de disponibilité acceptées par FreeTransfert, en jours
+var Availabilities = []int{1, 7, 30}
+
+// Limites appliquées par FreeTransfert au message et aux destinataires
+const (
+	MaxMessageLength = 1000
+	MaxRecipients    = 10
+)
+
+// TransferOptions regroupe les options facultatives d'un nouveau transfert
+type TransferOptions struct {
+	// Mot de passe demandé avant de télécharger les fichiers
+	Password string `json:"password,omitempty"`
+	// Durée de disponibilité en jours, 0 pour la durée par défaut du service
+	Availability int `json:"availability,omitempty"`
+	// Message joint au transfert et adresses e-mail qui reçoivent le lien
+	Message    string   `json:"message,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+	// Prévenir l'expéditeur à chaque téléchargement, avec ou sans destinataires
+	NotifyOnDownload bool `json:"notifyOnDownload,omitempty"`
+}
+
+// ParseAvailability convertit une durée de disponibilité (1d, 7d, 30d) en nombre de jours
+func ParseAvailability(value string) (int, error) {
+	days, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(value)), "d"))
+	if err == nil {
+		for _, allowed := range Availabilities {
+			if days == allowed {
+				return days, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("durée de disponibilité invalide : %s (valeurs possibles : 1d, 7d, 30d)", value)
+}
+
+// ParseRecipients découpe une liste d'adresses e-mail séparées par des virgules
+// et vérifie chacune d'elles. Les doublons sont ignorés.
+func ParseRecipients(value string) ([]string, error) {
+	var recipients []string
+	seen := map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(field)
+		if err != nil || address.Name != "" {
+			return nil, fmt.Errorf("adresse e-mail invalide : %s", field)
+		}
+		if key := strings.ToLower(address.Address); !seen[key] {
+			seen[key] = true
+			recipients = append(recipients, address.Address)
+		}
+	}
+	return recipients, nil
+}
+
+// Validate vérifie les options avant de créer le transfert
+func (o TransferOptions) Validate() error {
+	if o.Availability != 0 {
+		if _, err := ParseAvailability(strconv.Itoa(o.Availability)); err != nil {
+			return err
+		}
+	}
+	if n := utf8.RuneCountInString(o.Message); n > MaxMessageLength {
+		return fmt.Errorf("message trop long : %d caractères (maximum %d)", n, MaxMessageLength)
+	}
+	if len(o.Recipients) > MaxRecipients {
+		return fmt.Errorf("trop de destinataires : %d (maximum %d)", len(o.Recipients), MaxRecipients)
+	}
+	for _, recipient := range o.Recipients {
+		if _, err := ParseRecipients(recipient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Corps de la requête de création d'un transfert
+type createRequest struct {
+	Files []File `json:"files"`
+	TransferOptions
+}
+
+// CreateTransfer déclare un nouveau transfert contenant les fichiers donnés
+func (c *Client) CreateTransfer(ctx context.Context, files []File, opts TransferOptions) (*CreatedTransfer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	var created CreatedTransfer
+	in := createRequest{Files: files, TransferOptions: opts}
+	if err := c.do(ctx, http.MethodPost, "/transfers", nil, in, &created); err != nil {
+		return nil, err
+	}
+	if created.Key == "" || len(created.Files) != len(files) {
+		return nil, errors.New("réponse de l'API incomplète")
+	}
+	return &created, nil
+}
+
+// DeleteTransfer supprime un transfert grâce au jeton reçu à sa création
+func (c *Client) DeleteTransfer(ctx context.Context, key, deleteKey string) error {
+	if deleteKey == "" {
+		return errors.New("aucun jeton de suppression fourni")
+	}
+	in := map[string]string{"deleteKey": deleteKey}
+	return c.do(ctx, http.MethodDelete, "/transfers/"+url.PathEscape(key), nil, in, nil)
+}
+
+// Upload envoie le contenu d'un fichier vers l'adresse fournie à la création du transfert.
+// size vaut -1 si la taille n'est pas connue à l'avance : le contenu est alors d'abord copié
+// dans un fichier temporaire, les adresses d'envoi signées refusant en général un envoi sans Content-Length.
+func (c *Client) Upload(ctx context.Context, target UploadTarget, r io.Reader, size int64) error {
+	if target.UploadURL == "" {
+		return fmt.Errorf("aucune adresse d'envoi pour %s", target.Path)
+	}
+	if size < 0 {
+		spool, err := os.CreateTemp(c.TempDir, "upload-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		if size, err = io.Copy(spool, r); err != nil {
+			return err
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = spool
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.UploadURL, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// Content est le contenu d'un fichier en cours de téléchargement
+type Content struct {
+	io.ReadCloser
+	// Position du premier octet du contenu dans le fichier
+	Offset int64
+	// Taille du contenu restant à lire, -1 si elle est inconnue
+	Length int64
+	// Taille totale du fichier annoncée par le serveur pour une requête partielle, -1 si elle est inconnue
+	Size int64
+	// ETag du fichier, vide si le serveur n'en fournit pas
+	ETag string
+}
+
+// Download ouvre le contenu d'une adresse signée à partir de offset.
+// Si le serveur ne sait pas reprendre à cette position ou si le fichier a changé depuis etag,
+// le contenu complet est renvoyé et Content.Offset vaut 0.
+// L'appelant doit fermer le contenu renvoyé.
+func (c *Client) Download(ctx context.Context, signed *SignedURL, offset int64, etag string) (*Content, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signed.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag != "" {
+			req.Header.Set("If-Range", etag)
+		}
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		start, err := rangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			resp.Body.Close()
+			return c.Download(ctx, signed, 0, "")
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		resp.Body.Close()
+		//Le fichier partiel contient déjà tout le fichier : il ne reste rien à télécharger
+		if size := unsatisfiedSize(resp.Header.Get("Content-Range")); size == offset {
+			return &Content{
+				ReadCloser: http.NoBody,
+				Offset:     offset,
+				Length:     0,
+				Size:       size,
+				ETag:       resp.Header.Get("ETag"),
+			}, nil
+		}
+		//La position demandée n'existe plus, on recommence depuis le début
+		return c.Download(ctx, signed, 0, "")
+	case resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, &APIError{StatusCode: resp.StatusCode}
+	default:
+		offset = 0
+	}
+
+	size := resp.ContentLength
+	if offset > 0 {
+		size = rangeSize(resp.Header.Get("Content-Range"))
+	}
+	return &Content{
+		ReadCloser: resp.Body,
+		Offset:     offset,
+		Length:     resp.ContentLength,
+		Size:       size,
+		ETag:       resp.Header.Get("ETag"),
+	}, nil
+}
+
+// ErrRangeUnsupported est renvoyée lorsque le serveur ne permet pas de télécharger une partie d'un fichier
+var ErrRangeUnsupported = errors.New("le serveur ne permet pas de télécharger une partie du fichier")
+
+// ErrFileChanged est renvoyée lorsque le fichier a changé sur le serveur depuis l'ETag demandé
+var ErrFileChanged = errors.New("le fichier a changé sur le serveur pendant le téléchargement")
+
+// DownloadRange ouvre les octets start à end (inclus) d'une adresse signée.
+// Si etag n'est pas vide, la partie n'est envoyée que si le fichier n'a pas changé (If-Match),
+// sinon ErrFileChanged est renvoyée. Un ETag faible (W/) ne peut pas servir à cette comparaison et est ignoré.
+// Renvoie ErrRangeUnsupported si le serveur répond avec le fichier complet,
+// ou si la partie demandée n'existe pas (416), par exemple pour un fichier vide.
+// L'appelant doit fermer le contenu renvoyé.
+func (c *Client) DownloadRange(ctx context.Context, signed *SignedURL, start, end int64, etag string) (*Content, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signed.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if etag != "" && !strings.HasPrefix(etag, "W/") {
+		req.Header.Set("If-Match", etag)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if got, err := rangeStart(resp.Header.Get("Content-Range")); err != nil || got != start {
+			resp.Body.Close()
+			return nil, ErrRangeUnsupported
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, ErrRangeUnsupported
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		resp.Body.Close()
+		return nil, ErrFileChanged
+	case resp.StatusCode >= 300:
+		resp.Body.Close()
+		return nil, &APIError{StatusCode: resp.StatusCode}
+	default:
+		resp.Body.Close()
+		return nil, ErrRangeUnsupported
+	}
+
+	return &Content{
+		ReadCloser: resp.Body,
+		Offset:     start,
+		Length:     resp.ContentLength,
+		Size:       rangeSize(resp.Header.Get("Content-Range")),
+		ETag:       resp.Header.Get("ETag"),
+	}, nil
+}
+
+// Lire la position de départ d'un en-tête Content-Range de la forme "bytes 100-199/200"
+func rangeStart(header string) (int64, error) {
+	var start, end int64
+	var total string
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%s", &start, &end, &total); err != nil {
+		return 0, err
+	}
+	return start, nil
+}
+
+// Lire la taille totale d'un en-tête Content-Range de réponse 416, de la forme "bytes */200",
+// -1 si elle est inconnue
+func unsatisfiedSize(header string) int64 {
+	var total int64
+	if _, err := fmt.Sscanf(header, "bytes */%d", &total); err != nil {
+		return -1
+	}
+	return total
+}
+
+// Lire la taille totale d'un en-tête Content-Range de la forme "bytes 100-199/200", -1 si elle est inconnue
+func rangeSize(header string) int64 {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return -1
+	}
+	return total
+}
@@ -3,6 +3,7 @@ module freetranscli
 go 1.19
 
 require (
+	github.com/klauspost/compress v1.17.4
 	github.com/mdp/qrterminal v1.0.1
 	github.com/schollz/progressbar/v3 v3.13.0
 	github.com/spf13/cobra v1.6.1
@@ -43,17 +44,17 @@ require (
 	github.com/fatih/color v1.14.1 // direct
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51 // indirect
-	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-colorable v0.1.13
 	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d // indirect
 	github.com/nu7hatch/gouuid v0.0.0-20131221200532-179d4d0c4d8d // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.5.0 // indirect
-	golang.org/x/term v0.5.0 // indirect
+	golang.org/x/term v0.5.0
 	golang.org/x/text v0.7.0 // indirect
 )
 
 require (
 	github.com/inancgumus/screen v0.0.0-20190314163918-06e984b86ed3 // direct
-	golang.org/x/crypto v0.6.0 // indirect
+	golang.org/x/crypto v0.6.0 // direct
 )